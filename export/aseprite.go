@@ -0,0 +1,209 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type asepriteRect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type asepriteFrame struct {
+	FileName         string       `json:"filename"`
+	Frame            asepriteRect `json:"frame"`
+	Rotated          bool         `json:"rotated"`
+	Trimmed          bool         `json:"trimmed"`
+	SpriteSourceSize asepriteRect `json:"spriteSourceSize"`
+	SourceSize       model.Size   `json:"sourceSize"`
+	Duration         int          `json:"duration"`
+}
+
+type asepriteTag struct {
+	Name      string `json:"name"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+}
+
+type asepriteSliceKey struct {
+	Frame  int           `json:"frame"`
+	Bounds asepriteRect  `json:"bounds"`
+	Center *asepriteRect `json:"center"`
+	Pivot  *model.Point  `json:"pivot"`
+}
+
+type asepriteSlice struct {
+	Name string             `json:"name"`
+	Keys []asepriteSliceKey `json:"keys"`
+}
+
+type asepriteSheet struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   struct {
+		App       string          `json:"app"`
+		Version   string          `json:"version"`
+		Image     string          `json:"image"`
+		Format    string          `json:"format"`
+		Size      model.Size      `json:"size"`
+		FrameTags []asepriteTag   `json:"frameTags"`
+		Slices    []asepriteSlice `json:"slices"`
+	} `json:"meta"`
+}
+
+// isAseprite recognises the json sheet whose meta.app is aseprite.
+func isAseprite(data []byte) bool {
+	var raw struct {
+		Meta struct {
+			App string `json:"app"`
+		} `json:"meta"`
+	}
+	return json.Unmarshal(data, &raw) == nil && strings.Contains(strings.ToLower(raw.Meta.App), "aseprite")
+}
+
+// importAseprite imports the json sheet exported by Aseprite, in the hash or array layout.
+// Frame durations are kept on the sprites, frame tags become animations,
+// the 9-patch center and the pivot of the slices become the border and the pivot of the frames they apply to.
+// Frame names without image extension, e.g. "run 0.aseprite", are renamed to png.
+func importAseprite(data []byte) (*model.AtlasInfo, error) {
+	var sheet asepriteSheet
+	if err := json.Unmarshal(data, &sheet); err != nil {
+		return nil, err
+	}
+	frames, err := decodeFrames(sheet.Frames, func(f *asepriteFrame, name string) { f.FileName = name })
+	if err != nil {
+		return nil, err
+	}
+	sprites := make([]model.Sprite, len(frames))
+	for i, f := range frames {
+		frame := model.NewRectByPosAndSize(f.Frame.X, f.Frame.Y, f.Frame.W, f.Frame.H)
+		if f.Rotated {
+			frame = frame.Rotated()
+		}
+		sprite := model.Sprite{
+			FileName: asepriteImageName(f.FileName),
+			Frame:    frame,
+			SrcRect:  f.SourceSize,
+			Rotated:  f.Rotated,
+			Trimmed:  f.Trimmed,
+			Duration: f.Duration,
+		}
+		if sprite.SrcRect.W == 0 || sprite.SrcRect.H == 0 {
+			sprite.SrcRect = model.Size{W: f.Frame.W, H: f.Frame.H}
+		}
+		if f.Trimmed {
+			s := f.SpriteSourceSize
+			sprite.TrimmedRect = model.NewRectByPosAndSize(s.X, s.Y, s.W, s.H)
+		}
+		sprites[i] = sprite
+	}
+
+	for _, slice := range sheet.Meta.Slices {
+		keys := slice.Keys
+		sort.SliceStable(keys, func(i, j int) bool { return keys[i].Frame < keys[j].Frame })
+		for k, key := range keys {
+			// a key applies until the next key
+			end := len(sprites)
+			if k+1 < len(keys) {
+				end = min(keys[k+1].Frame, end)
+			}
+			for i := max(key.Frame, 0); i < end; i++ {
+				sprite := &sprites[i]
+				if key.Center != nil {
+					sprite.Border = model.Border{
+						L: key.Bounds.X + key.Center.X,
+						T: key.Bounds.Y + key.Center.Y,
+						R: sprite.SrcRect.W - key.Bounds.X - key.Center.X - key.Center.W,
+						B: sprite.SrcRect.H - key.Bounds.Y - key.Center.Y - key.Center.H,
+					}
+				}
+				if key.Pivot != nil && sprite.SrcRect.W > 0 && sprite.SrcRect.H > 0 {
+					sprite.Pivot = &model.Pivot{
+						X: float64(key.Bounds.X+key.Pivot.X) / float64(sprite.SrcRect.W),
+						Y: float64(key.Bounds.Y+key.Pivot.Y) / float64(sprite.SrcRect.H),
+					}
+				}
+			}
+		}
+	}
+
+	var animations []model.Animation
+	for _, tag := range sheet.Meta.FrameTags {
+		if tag.From < 0 || tag.To >= len(sprites) || tag.From > tag.To {
+			return nil, fmt.Errorf("frame tag %s: frames %d-%d out of range", tag.Name, tag.From, tag.To)
+		}
+		animation := model.Animation{
+			Name:      tag.Name,
+			Direction: tag.Direction,
+		}
+		for i := tag.From; i <= tag.To; i++ {
+			animation.Frames = append(animation.Frames, sprites[i].FileName)
+		}
+		animations = append(animations, animation)
+	}
+
+	return &model.AtlasInfo{
+		Meta: model.Meta{
+			Repo:    sheet.Meta.App,
+			Format:  sheet.Meta.Format,
+			Version: sheet.Meta.Version,
+		},
+		Atlases: []model.Atlas{
+			{
+				Name:    sheet.Meta.Image,
+				Size:    sheet.Meta.Size,
+				Sprites: sprites,
+			},
+		},
+		Animations: animations,
+	}, nil
+}
+
+// decodeFrames decodes the frames array, or the frames hash keeping the order of its keys,
+// naming the frames of the hash with setName.
+func decodeFrames[T any](data json.RawMessage, setName func(frame *T, name string)) ([]T, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("no frames found")
+	}
+	var frames []T
+	if data[0] == '[' {
+		err := json.Unmarshal(data, &frames)
+		return frames, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var frame T
+		if err = decoder.Decode(&frame); err != nil {
+			return nil, err
+		}
+		setName(&frame, token.(string))
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// asepriteImageName replaces the extension of frame names which are not images with .png.
+func asepriteImageName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".bmp", ".tiff", ".webp":
+		return name
+	}
+	return trimExt(name) + ".png"
+}
@@ -0,0 +1,178 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type cSprite struct {
+	Enum    string
+	Name    string
+	Atlas   int
+	Frame   model.Rect
+	Offset  model.Point
+	Source  model.Size
+	Rotated int
+}
+
+type cTemplateData struct {
+	Meta    model.Meta
+	Guard   string
+	Prefix  string // enum prefix, upper case
+	Type    string // type prefix, lower case
+	Atlases []string
+	Sprites []cSprite
+}
+
+// CHeaderExporter exports a C/C++ header with an enum of sprite ids
+// and a const array of sprite frames indexed by them.
+type CHeaderExporter struct {
+	ext string
+	// Prefix of the enum constants and types, "SPRITE" by default,
+	// e.g. SPRITE_RUN_01, sprite_id and sprite_frames.
+	Prefix string
+	// Guard is the include guard macro, <PREFIX>_ATLAS_H by default.
+	Guard string
+}
+
+func (c *CHeaderExporter) Ext() string {
+	return c.ext
+}
+func (c *CHeaderExporter) SetExt(ext string) {
+	c.ext = ext
+}
+
+func (c *CHeaderExporter) SetOption(key, value string) error {
+	switch key {
+	case "prefix":
+		c.Prefix = value
+	case "guard":
+		c.Guard = value
+	default:
+		return unknownOption(c.ext, key)
+	}
+	return nil
+}
+
+func (c *CHeaderExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	prefix := upperSnakeCase(c.Prefix)
+	if prefix == "" {
+		prefix = "SPRITE"
+	}
+	if prefix[0] >= '0' && prefix[0] <= '9' {
+		return nil, fmt.Errorf("invalid prefix %q", c.Prefix)
+	}
+	guard := c.Guard
+	if guard == "" {
+		guard = prefix + "_ATLAS_H"
+	}
+	if upperSnakeCase(guard) != guard {
+		return nil, fmt.Errorf("invalid include guard %q", guard)
+	}
+	data := cTemplateData{
+		Meta:   atlasInfo.Meta,
+		Guard:  guard,
+		Prefix: prefix,
+		Type:   strings.ToLower(prefix),
+	}
+	namer := newIdentNamer()
+	// reserve the count constant of the enum
+	namer.name(prefix+"_", "COUNT", "")
+	for i, atlas := range atlasInfo.Atlases {
+		data.Atlases = append(data.Atlases, cString(filepath.ToSlash(atlas.Name)))
+		for j, sprite := range atlas.Sprites {
+			s := cSprite{
+				Enum:   namer.name(prefix+"_", upperSnakeCase(trimExt(sprite.FileName)), strconv.Itoa(j)),
+				Name:   cString(sprite.FileName),
+				Atlas:  i,
+				Frame:  sprite.Frame,
+				Offset: spriteOffset(sprite),
+				Source: sprite.SrcRect,
+			}
+			if sprite.Rotated {
+				s.Rotated = 1
+			}
+			data.Sprites = append(data.Sprites, s)
+		}
+	}
+
+	if len(data.Sprites) == 0 {
+		return nil, fmt.Errorf("no sprite found")
+	}
+
+	tmpl, err := template.New(c.Ext()).Parse(cHeaderTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+func (c *CHeaderExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	return nil, errors.New("c header format does not support import")
+}
+
+// cString quotes s as a C string literal, non-ascii bytes are escaped in octal.
+func cString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '"' || b == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b < 0x20 || b >= 0x7f || b == '?':
+			// '?' is escaped to avoid trigraphs
+			_, _ = fmt.Fprintf(&sb, "\\%03o", b)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+const cHeaderTemplate = `/* Generated by spritepacker {{.Meta.Version}}, do not edit. */
+#ifndef {{.Guard}}
+#define {{.Guard}}
+
+typedef enum {{.Type}}_id {
+{{- range $i, $s := .Sprites}}
+	{{$s.Enum}} = {{$i}},
+{{- end}}
+	{{.Prefix}}_COUNT
+} {{.Type}}_id;
+
+typedef struct {{.Type}}_frame {
+	const char *name;
+	int atlas;               /* index in {{.Type}}_atlases */
+	int x, y, w, h;          /* region in the atlas image */
+	int offset_x, offset_y;  /* position of the trimmed sprite in the source image */
+	int source_w, source_h;  /* size of the source image */
+	int rotated;             /* stored rotated 90 degrees clockwise */
+} {{.Type}}_frame;
+
+static const char *const {{.Type}}_atlases[] = {
+{{- range .Atlases}}
+	{{.}},
+{{- end}}
+};
+
+static const {{.Type}}_frame {{.Type}}_frames[{{.Prefix}}_COUNT] = {
+{{- range .Sprites}}
+	{ {{.Name}}, {{.Atlas}}, {{.Frame.X}}, {{.Frame.Y}}, {{.Frame.W}}, {{.Frame.H}}, {{.Offset.X}}, {{.Offset.Y}}, {{.Source.W}}, {{.Source.H}}, {{.Rotated}} },
+{{- end}}
+};
+
+#endif /* {{.Guard}} */
+`
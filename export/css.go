@@ -0,0 +1,242 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type cssSprite struct {
+	Name     string
+	Class    string
+	Image    string
+	X, Y     string // background position
+	W, H     string // size of the element, unrotated
+	FW, FH   string // size of the region in the atlas
+	BgW, BgH string // background size, only set when scaled
+	Rotated  bool
+}
+
+type cssTemplateData struct {
+	Prefix  string
+	Sprites []cssSprite
+	Css     string
+}
+
+// CssExporter exports a css sprite sheet with one class per sprite.
+// Rotated sprites are drawn by a ::before element rotated back with a transform.
+type CssExporter struct {
+	ext string
+	// Scale is the pixel ratio of the atlas images, e.g. 2 for @2x atlases.
+	// The css sizes are divided by it, 0 is treated as 1.
+	Scale float64
+	// Prefix of the class names, "sprite" by default.
+	Prefix string
+}
+
+func (c *CssExporter) Ext() string {
+	return c.ext
+}
+func (c *CssExporter) SetExt(ext string) {
+	c.ext = ext
+}
+
+func (c *CssExporter) SetOption(key, value string) error {
+	switch key {
+	case "scale":
+		scale, err := parseFloatOption(key, value)
+		if err != nil {
+			return err
+		}
+		c.Scale = scale
+	case "prefix":
+		c.Prefix = value
+	default:
+		return unknownOption(c.ext, key)
+	}
+	return nil
+}
+
+func (c *CssExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	data, err := c.templateData(atlasInfo)
+	if err != nil {
+		return nil, err
+	}
+	return executeCssTemplate(cssTemplate, data)
+}
+
+func (c *CssExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	return nil, errors.New("css format does not support import")
+}
+
+func (c *CssExporter) templateData(atlasInfo *model.AtlasInfo) (*cssTemplateData, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	scale := c.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	prefix := cssIdent(c.Prefix)
+	if prefix == "" {
+		prefix = "sprite"
+	}
+	px := func(v int) string {
+		if v == 0 {
+			return "0"
+		}
+		return formatFloat(float64(v)/scale) + "px"
+	}
+
+	data := &cssTemplateData{Prefix: prefix}
+	used := make(map[string]bool)
+	for _, atlas := range atlasInfo.Atlases {
+		for _, sprite := range atlas.Sprites {
+			size := sprite.Frame.Size
+			if sprite.Rotated {
+				size = size.Rotated()
+			}
+			base := prefix + "-" + cssIdent(strings.TrimSuffix(sprite.FileName, filepath.Ext(sprite.FileName)))
+			// keep class names unique when different names sanitize to the same identifier,
+			// the numbered names may be the names of other sprites too
+			class := base
+			for i := 2; used[class]; i++ {
+				class = base + "-" + strconv.Itoa(i)
+			}
+			used[class] = true
+			s := cssSprite{
+				Name:    sprite.FileName,
+				Class:   class,
+				Image:   atlas.Name,
+				X:       px(-sprite.Frame.X),
+				Y:       px(-sprite.Frame.Y),
+				W:       px(size.W),
+				H:       px(size.H),
+				FW:      px(sprite.Frame.W),
+				FH:      px(sprite.Frame.H),
+				Rotated: sprite.Rotated,
+			}
+			if scale != 1 {
+				s.BgW = px(atlas.Size.W)
+				s.BgH = px(atlas.Size.H)
+			}
+			data.Sprites = append(data.Sprites, s)
+		}
+	}
+	return data, nil
+}
+
+// HtmlExporter exports a standalone html page previewing every sprite of the css sprite sheet.
+type HtmlExporter struct {
+	CssExporter
+}
+
+func (h *HtmlExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	data, err := h.templateData(atlasInfo)
+	if err != nil {
+		return nil, err
+	}
+	css, err := executeCssTemplate(cssTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	data.Css = string(css)
+	return executeCssTemplate(htmlTemplate, data)
+}
+
+func executeCssTemplate(templateStr string, data *cssTemplateData) ([]byte, error) {
+	tmpl, err := template.New("css").Parse(templateStr)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+var cssInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// cssIdent converts a name into a valid css class identifier.
+func cssIdent(name string) string {
+	return strings.Trim(cssInvalidChars.ReplaceAllString(name, "-"), "-")
+}
+
+const cssTemplate = `.{{.Prefix}} {
+	display: inline-block;
+	background-repeat: no-repeat;
+}
+{{- range .Sprites}}
+{{- if .Rotated}}
+
+.{{.Class}} {
+	position: relative;
+	overflow: hidden;
+	width: {{.W}};
+	height: {{.H}};
+}
+
+.{{.Class}}::before {
+	content: "";
+	position: absolute;
+	left: 0;
+	top: 0;
+	width: {{.FW}};
+	height: {{.FH}};
+	background: url("{{.Image}}") {{.X}} {{.Y}} no-repeat;
+	{{- if .BgW}}
+	background-size: {{.BgW}} {{.BgH}};
+	{{- end}}
+	transform-origin: 0 0;
+	transform: translateY({{.FW}}) rotate(-90deg);
+}
+{{- else}}
+
+.{{.Class}} {
+	width: {{.W}};
+	height: {{.H}};
+	background-image: url("{{.Image}}");
+	background-position: {{.X}} {{.Y}};
+	{{- if .BgW}}
+	background-size: {{.BgW}} {{.BgH}};
+	{{- end}}
+}
+{{- end}}
+{{- end}}
+`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<title>Sprite Preview</title>
+	<style>
+		body { font-family: sans-serif; }
+		.preview { display: flex; flex-wrap: wrap; gap: 16px; }
+		.preview figure { margin: 0; text-align: center; }
+		.preview figcaption { font-size: 12px; margin-top: 4px; }
+	</style>
+	<style>
+{{.Css}}
+	</style>
+</head>
+<body>
+	<div class="preview">
+		{{- range .Sprites}}
+		<figure>
+			<div class="{{$.Prefix}} {{.Class}}"></div>
+			<figcaption>{{html .Name}}<br>.{{.Class}}</figcaption>
+		</figure>
+		{{- end}}
+	</div>
+</body>
+</html>
+`
@@ -20,23 +20,20 @@ type ParseFunc func([]byte) (*model.AtlasInfo, error)
 
 func NewTemplateExporter(templateStr string, parseFunc ParseFunc) *TemplateExporter {
 	return &TemplateExporter{
-		ext:         ".tmpl",
-		templateStr: templateStr,
-		parseFunc:   parseFunc,
-		templateFunc: template.FuncMap{
-			"isLast": func(index, length int) bool {
-				return index == length-1
-			},
-		},
+		ext:          ".tmpl",
+		templateStr:  templateStr,
+		parseFunc:    parseFunc,
+		templateFunc: TemplateFuncs(),
 	}
 }
 
-func (e *TemplateExporter) Export(atlas *model.AtlasInfo) ([]byte, error) {
-	if e.templateStr == "" {
-		return nil, errors.New("template string is empty")
-	}
+// Polygons is true as the templates can write the vertices and the verticesUV of the sprites.
+func (e *TemplateExporter) Polygons() bool {
+	return true
+}
 
-	tmpl, err := template.New("export").Funcs(e.templateFunc).Parse(e.templateStr)
+func (e *TemplateExporter) Export(atlas *model.AtlasInfo) ([]byte, error) {
+	tmpl, err := e.parse()
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +46,13 @@ func (e *TemplateExporter) Export(atlas *model.AtlasInfo) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func (e *TemplateExporter) parse() (*template.Template, error) {
+	if e.templateStr == "" {
+		return nil, errors.New("template string is empty")
+	}
+	return template.New("export").Funcs(e.templateFunc).Parse(e.templateStr)
+}
+
 func (e *TemplateExporter) Import(data []byte) (*model.AtlasInfo, error) {
 	if e.parseFunc == nil {
 		return nil, errors.New("parse function is not provided")
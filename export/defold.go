@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type defoldImage struct {
+	Image    string
+	TrimMode string
+}
+
+type defoldTemplateData struct {
+	Images []defoldImage
+	// MaxPageSize is the size of the atlas pages, 0 for a single page
+	MaxPageSize model.Size
+}
+
+// DefoldExporter exports a Defold .atlas listing the sprite images,
+// Defold packs the atlas itself when building, trimmed sprites keep a rectangular trim.
+type DefoldExporter struct {
+	ext string
+	// ImagePrefix is the project path of the sprite images, "/" by default, e.g. "/assets/sprites/".
+	ImagePrefix string
+}
+
+func (d *DefoldExporter) Ext() string {
+	return d.ext
+}
+func (d *DefoldExporter) SetExt(ext string) {
+	d.ext = ext
+}
+
+func (d *DefoldExporter) SetOption(key, value string) error {
+	switch key {
+	case "imagePrefix":
+		d.ImagePrefix = value
+	default:
+		return unknownOption(d.ext, key)
+	}
+	return nil
+}
+
+func (d *DefoldExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	prefix := d.ImagePrefix
+	if prefix == "" {
+		prefix = "/"
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	data := defoldTemplateData{}
+	if len(atlasInfo.Atlases) > 1 {
+		// keep the pages of the packed atlases
+		for _, atlas := range atlasInfo.Atlases {
+			data.MaxPageSize.W = max(data.MaxPageSize.W, atlas.Size.W)
+			data.MaxPageSize.H = max(data.MaxPageSize.H, atlas.Size.H)
+		}
+	}
+	for _, atlas := range atlasInfo.Atlases {
+		for _, sprite := range atlas.Sprites {
+			image := defoldImage{
+				Image:    strconv.Quote(prefix + strings.TrimPrefix(filepath.ToSlash(sprite.FileName), "/")),
+				TrimMode: "SPRITE_TRIM_MODE_OFF",
+			}
+			if sprite.Trimmed {
+				image.TrimMode = "SPRITE_TRIM_MODE_4"
+			}
+			data.Images = append(data.Images, image)
+		}
+	}
+
+	tmpl, err := template.New(d.Ext()).Parse(defoldTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+func (d *DefoldExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	return nil, errors.New("defold atlas format does not support import")
+}
+
+const defoldTemplate = `{{range .Images}}images {
+  image: {{.Image}}
+  sprite_trim_mode: {{.TrimMode}}
+}
+{{end}}margin: 0
+extrude_borders: 2
+inner_padding: 0
+max_page_width: {{.MaxPageSize.W}}
+max_page_height: {{.MaxPageSize.H}}
+`
@@ -1,8 +1,8 @@
 package export
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/91xusir/spritepacker/model"
 	"os"
 	"path/filepath"
@@ -16,38 +16,170 @@ type Exporter interface {
 	SetExt(ext string)
 }
 
+// PerAtlasExporter is implemented by exporters whose format can only describe one atlas.
+// When the AtlasInfo has several atlases, ExporterManager writes one file per atlas,
+// named like the atlas images: <name>_<index><ext>.
+type PerAtlasExporter interface {
+	Exporter
+	PerAtlas() bool
+}
+
+// Detector is implemented by importers able to recognise their format from the content,
+// ExporterManager.Import uses it when the file extension does not match the content.
+type Detector interface {
+	Detect(data []byte) bool
+}
+
+// PolygonExporter is implemented by exporters whose format carries the sprite polygons.
+// The frames packed by pack.AlgoPolygon overlap, only the formats carrying the polygons draw them correctly.
+type PolygonExporter interface {
+	Polygons() bool
+}
+
+// AtlasFileNamer is implemented by per atlas exporters whose files must be named after the atlas image,
+// e.g. Unity expects <image>.meta next to the texture. The name is relative to the directory of the export file.
+type AtlasFileNamer interface {
+	AtlasFileName(atlas model.Atlas) string
+}
+
 type ExporterManager struct {
 	exporters map[string]Exporter
+	order     []string        // extensions in registration order, the order of format detection
+	stripExt  map[string]bool // extensions whose sprite names are exported without file extension
+}
+
+// checkNoTransforms returns an error if a sprite is a transformed copy of another one,
+// for the formats which only describe the frames and would show the pixels of the sprite untransformed.
+func checkNoTransforms(atlasInfo *model.AtlasInfo) error {
+	for _, atlas := range atlasInfo.Atlases {
+		for _, sprite := range atlas.Sprites {
+			if sprite.Transform != "" {
+				return fmt.Errorf("sprite %s is %s of %s, the format can not carry the sprite transforms", sprite.FileName, sprite.Transform, sprite.AliasOf)
+			}
+		}
+	}
+	return nil
 }
 
 func NewExportManager() *ExporterManager {
 	return &ExporterManager{
 		exporters: make(map[string]Exporter),
+		stripExt:  make(map[string]bool),
 	}
 }
 
 func (m *ExporterManager) Register(ext string, exporter Exporter) {
 	ext = strings.ToLower(ext)
 	exporter.SetExt(ext)
+	if _, ok := m.exporters[ext]; !ok {
+		m.order = append(m.order, ext)
+	}
 	m.exporters[ext] = exporter
 }
 
+// fileExt returns the extension of the file name, the longest registered one it ends with,
+// e.g. ".defold.atlas" for "atlas.defold.atlas".
+func (m *ExporterManager) fileExt(fileName string) string {
+	name := strings.ToLower(filepath.Base(fileName))
+	ext := filepath.Ext(name)
+	for _, e := range m.order {
+		if len(e) > len(ext) && strings.HasSuffix(name, e) {
+			ext = e
+		}
+	}
+	return ext
+}
+
 func (m *ExporterManager) Export(fileName string, atlas *model.AtlasInfo) error {
-	ext := strings.ToLower(filepath.Ext(fileName))
-	var data []byte
-	var err error
-	if exporter, ok := m.exporters[ext]; ok {
-		data, err = exporter.Export(atlas)
+	files, err := m.render(fileName, atlas)
+	if err != nil {
+		return err
+	}
+	return writeFiles(files)
+}
+
+// exportFile is the content of an export file, rendered before writing.
+type exportFile struct {
+	name string
+	data []byte
+}
+
+// render exports the atlas info in the format of fileName, one file per atlas for the per atlas exporters.
+func (m *ExporterManager) render(fileName string, atlas *model.AtlasInfo) ([]exportFile, error) {
+	ext := m.fileExt(fileName)
+	exporter, ok := m.exporters[ext]
+	if !ok {
+		return nil, errors.New("unsupported file type")
+	}
+	if m.stripExt[ext] {
+		atlas = withoutSpriteExt(atlas)
+	}
+	perAtlas := false
+	if e, ok := exporter.(PerAtlasExporter); ok {
+		perAtlas = e.PerAtlas()
+	}
+	namer, named := exporter.(AtlasFileNamer)
+	if perAtlas && (len(atlas.Atlases) > 1 || named) {
+		fileExt := fileName[len(fileName)-len(ext):]
+		baseName := strings.TrimSuffix(fileName, fileExt)
+		files := make([]exportFile, 0, len(atlas.Atlases))
+		for i := range atlas.Atlases {
+			single := &model.AtlasInfo{
+				Meta:    atlas.Meta,
+				Atlases: atlas.Atlases[i : i+1],
+			}
+			atlasFileName := fmt.Sprintf("%s_%d%s", baseName, i, fileExt)
+			if named {
+				atlasFileName = filepath.Join(filepath.Dir(fileName), namer.AtlasFileName(atlas.Atlases[i]))
+			}
+			data, err := exporter.Export(single)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, exportFile{name: atlasFileName, data: data})
+		}
+		return files, nil
+	}
+	data, err := exporter.Export(atlas)
+	if err != nil {
+		return nil, err
+	}
+	return []exportFile{{name: fileName, data: data}}, nil
+}
+
+// ExportAll exports the atlas info in several formats, writing <baseName><ext> for every ext.
+// Every format is exported before writing, nothing is written if one of them fails.
+//
+// Example:
+//
+//	err := manager.ExportAll("output/atlas", []string{".json", ".tpsheet"}, atlasInfo)
+func (m *ExporterManager) ExportAll(baseName string, exts []string, atlas *model.AtlasInfo) error {
+	for _, ext := range exts {
+		if _, ok := m.exporters[strings.ToLower(ext)]; !ok {
+			return fmt.Errorf("unsupported file type %s", ext)
+		}
+	}
+	var files []exportFile
+	for _, ext := range exts {
+		rendered, err := m.render(baseName+ext, atlas)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %v", ext, err)
 		}
-	} else {
-		return errors.New("unsupported file type")
+		files = append(files, rendered...)
 	}
-	if err = os.MkdirAll(filepath.Dir(fileName), os.ModePerm); err != nil {
-		return err
+	return writeFiles(files)
+}
+
+func writeFiles(files []exportFile) error {
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.name), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.name, file.data, 0644); err != nil {
+			return err
+		}
 	}
-	return os.WriteFile(fileName, data, 0644)
+	return nil
 }
 
 func (m *ExporterManager) Import(fileName string) (*model.AtlasInfo, error) {
@@ -55,18 +187,52 @@ func (m *ExporterManager) Import(fileName string) (*model.AtlasInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	ext := strings.ToLower(filepath.Ext(fileName))
-	if exporter, ok := m.exporters[ext]; ok {
-		return exporter.Import(data)
+	ext := m.fileExt(fileName)
+	exporter, err := m.Detect(ext, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return exporter.Import(data)
+}
+
+// Detect returns the importer for data.
+// The exporter registered for ext is used if it recognises data or can not detect formats,
+// otherwise every Detector is tried in registration order.
+func (m *ExporterManager) Detect(ext string, data []byte) (Exporter, error) {
+	exporter, ok := m.exporters[strings.ToLower(ext)]
+	if ok {
+		d, isDetector := exporter.(Detector)
+		if !isDetector || d.Detect(data) {
+			return exporter, nil
+		}
+	}
+	for _, e := range m.order {
+		if d, isDetector := m.exporters[e].(Detector); isDetector && d.Detect(data) {
+			return m.exporters[e], nil
+		}
+	}
+	if ok {
+		return nil, fmt.Errorf("the content is not in %s format and no other format recognises it", ext)
 	}
-	var atlas model.AtlasInfo
-	err = json.Unmarshal(data, &atlas)
-	return &atlas, err
+	return nil, fmt.Errorf("unrecognised atlas format")
 }
 
 func (m *ExporterManager) Init() *ExporterManager {
 	m.Register(".json", &JsonExporter{})
 	m.Register(".tpsheet", &GodotExporter{})
+	m.Register(".plist", &PlistExporter{})
+	m.Register(".xml", &SparrowExporter{})
+	m.Register(".css", &CssExporter{})
+	m.Register(".html", &HtmlExporter{})
+	m.Register(".meta", &UnityExporter{})
+	m.Register(".go", &GoExporter{})
+	m.Register(".h", &CHeaderExporter{})
+	m.Register(".yaml", &YamlExporter{})
+	m.Register(".yml", &YamlExporter{})
+	m.Register(".toml", &TomlExporter{})
+	m.Register(".lua", &LuaExporter{})
+	// .atlas is also the extension of the libGDX and Spine atlases
+	m.Register(".defold.atlas", &DefoldExporter{})
 	return m
 }
 
@@ -79,3 +245,51 @@ func (m *ExporterManager) RegisterTemplate(ext string, templateStr string, parse
 	exporter := NewTemplateExporter(templateStr, parseFunc)
 	m.Register(ext, exporter)
 }
+
+// LoadTemplate loads a go text/template file and registers it under ext.
+// If ext is empty, it is taken from the file name before ".tmpl",
+// e.g. "phaser.js.tmpl" is registered as ".js".
+//
+// Returns:
+//   - string: the registered extension
+//   - error
+func (m *ExporterManager) LoadTemplate(path string, ext string) (string, error) {
+	if ext == "" {
+		ext = filepath.Ext(strings.TrimSuffix(filepath.Base(path), ".tmpl"))
+		if ext == "" {
+			return "", fmt.Errorf("can not get the extension from template %s, name it like <name>.<ext>.tmpl", path)
+		}
+	}
+	ext = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	exporter := NewTemplateExporter(string(data), nil)
+	if _, err = exporter.parse(); err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %v", path, err)
+	}
+	m.Register(ext, exporter)
+	return ext, nil
+}
+
+// LoadTemplateDir loads every *.tmpl file of dir with LoadTemplate.
+//
+// Returns:
+//   - []string: the registered extensions
+//   - error
+func (m *ExporterManager) LoadTemplateDir(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	exts := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ext, err := m.LoadTemplate(path, "")
+		if err != nil {
+			return nil, err
+		}
+		exts = append(exts, ext)
+	}
+	return exts, nil
+}
@@ -0,0 +1,257 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"math"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// floatPoint is a point with fractional coordinates.
+type floatPoint struct {
+	X, Y float64
+}
+
+// uvRect is a rect normalized to the atlas size.
+type uvRect struct {
+	U0, V0 float64 // top-left corner
+	U1, V1 float64 // bottom-right corner
+	W, H   float64
+}
+
+// TemplateFuncs returns the functions available in export templates:
+//
+//   - isLast index length: whether index is the last one of a list of length
+//   - json value, jsonEscape string, xmlEscape string, luaEscape string: escaping,
+//     json returns a json value, the others return the content of a string literal
+//   - baseName name, trimExt name, ext name: file name parts, e.g. trimExt "a/run_01.png" -> "a/run_01"
+//   - camelCase, pascalCase, snakeCase, upperSnakeCase, lower, upper: case conversion of identifiers
+//   - add, sub, mul, div, mod: integer arithmetic
+//   - float, int, round, addf, subf, mulf, divf: float arithmetic
+//   - uv rect atlasSize: the rect normalized to the atlas size, with fields U0, V0, U1, V1, W and H
+//   - size sprite: the size of the trimmed sprite before rotation
+//   - offset sprite: the position of the trimmed sprite in the source image
+//   - margin sprite: the transparent margins removed by trimming, with fields L, T, R and B
+//   - centerOffset sprite: the offset between the trimmed and the source center with y up, with fields X and Y
+//   - verticesUV sprite: the vertices of the sprite polygon in atlas pixels, with fields X and Y
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"isLast": func(index, length int) bool {
+			return index == length-1
+		},
+		// escaping
+		"json":       toJson,
+		"jsonEscape": jsonEscape,
+		"xmlEscape":  xmlEscape,
+		"luaEscape":  luaEscape,
+		// names
+		"baseName":       filepath.Base,
+		"trimExt":        trimExt,
+		"ext":            filepath.Ext,
+		"camelCase":      camelCase,
+		"pascalCase":     pascalCase,
+		"snakeCase":      snakeCase,
+		"upperSnakeCase": upperSnakeCase,
+		"lower":          strings.ToLower,
+		"upper":          strings.ToUpper,
+		// arithmetic
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+		"mul": func(a, b int) int { return a * b },
+		"div": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"mod": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a % b, nil
+		},
+		"float": toFloat,
+		"int": func(v any) (int, error) {
+			f, err := toFloat(v)
+			return int(f), err
+		},
+		"round": func(v any, digits int) (float64, error) {
+			f, err := toFloat(v)
+			p := math.Pow10(digits)
+			return math.Round(f*p) / p, err
+		},
+		"addf": floatOp(func(a, b float64) float64 { return a + b }),
+		"subf": floatOp(func(a, b float64) float64 { return a - b }),
+		"mulf": floatOp(func(a, b float64) float64 { return a * b }),
+		"divf": func(a, b any) (float64, error) {
+			x, err := toFloat(a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := toFloat(b)
+			if err != nil {
+				return 0, err
+			}
+			if y == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return x / y, nil
+		},
+		// geometry
+		"uv":           uv,
+		"size":         spriteSize,
+		"offset":       spriteOffset,
+		"margin":       spriteMargin,
+		"centerOffset": spriteCenterOffset,
+		"verticesUV":   spriteVerticesUV,
+	}
+}
+
+func toJson(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func jsonEscape(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data[1 : len(data)-1])
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func luaEscape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; b {
+		case '\\', '"', '\'':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if b < 0x20 || b == 0x7f {
+				// decimal escapes are padded so that a following digit is not consumed
+				_, _ = fmt.Fprintf(&sb, "\\%03d", b)
+			} else {
+				sb.WriteByte(b)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	default:
+		return 0, fmt.Errorf("%v (%T) is not a number", v, v)
+	}
+}
+
+func floatOp(op func(a, b float64) float64) func(a, b any) (float64, error) {
+	return func(a, b any) (float64, error) {
+		x, err := toFloat(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toFloat(b)
+		if err != nil {
+			return 0, err
+		}
+		return op(x, y), nil
+	}
+}
+
+func uv(r model.Rect, atlasSize model.Size) (uvRect, error) {
+	if atlasSize.W == 0 || atlasSize.H == 0 {
+		return uvRect{}, errors.New("atlas size is empty")
+	}
+	w, h := float64(atlasSize.W), float64(atlasSize.H)
+	return uvRect{
+		U0: float64(r.X) / w,
+		V0: float64(r.Y) / h,
+		U1: float64(r.X+r.W) / w,
+		V1: float64(r.Y+r.H) / h,
+		W:  float64(r.W) / w,
+		H:  float64(r.H) / h,
+	}, nil
+}
+
+func spriteSize(s model.Sprite) model.Size {
+	if s.Rotated {
+		return s.Frame.Size.Rotated()
+	}
+	return s.Frame.Size
+}
+
+func spriteOffset(s model.Sprite) model.Point {
+	if s.Trimmed {
+		return s.TrimmedRect.Point
+	}
+	return model.Point{}
+}
+
+func spriteMargin(s model.Sprite) model.Border {
+	if !s.Trimmed {
+		return model.Border{}
+	}
+	size := spriteSize(s)
+	return model.Border{
+		L: s.TrimmedRect.X,
+		T: s.TrimmedRect.Y,
+		R: s.SrcRect.W - s.TrimmedRect.X - size.W,
+		B: s.SrcRect.H - s.TrimmedRect.Y - size.H,
+	}
+}
+
+func spriteCenterOffset(s model.Sprite) floatPoint {
+	if !s.Trimmed {
+		return floatPoint{}
+	}
+	size := spriteSize(s)
+	return floatPoint{
+		X: float64(2*s.TrimmedRect.X+size.W-s.SrcRect.W) / 2,
+		Y: float64(s.SrcRect.H-2*s.TrimmedRect.Y-size.H) / 2,
+	}
+}
+
+// spriteVerticesUV maps the vertices of the sprite polygon from the source sprite to the atlas,
+// a rotated sprite is stored 90 degrees clockwise.
+func spriteVerticesUV(s model.Sprite) []model.Point {
+	offset := spriteOffset(s)
+	size := spriteSize(s)
+	points := make([]model.Point, len(s.Vertices))
+	for i, v := range s.Vertices {
+		x, y := v.X-offset.X, v.Y-offset.Y
+		if s.Rotated {
+			x, y = size.H-y, x
+		}
+		points[i] = model.Point{X: s.Frame.X + x, Y: s.Frame.Y + y}
+	}
+	return points
+}
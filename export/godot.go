@@ -29,6 +29,8 @@ type godotTemplateData struct {
 
 type GodotExporter struct {
 	ext string
+	// ImagePrefix is prepended to the image path, e.g. "res://sprites/".
+	ImagePrefix string
 }
 
 func (g *GodotExporter) Ext() string {
@@ -38,7 +40,20 @@ func (g *GodotExporter) SetExt(ext string) {
 	g.ext = ext
 }
 
+func (g *GodotExporter) SetOption(key, value string) error {
+	switch key {
+	case "imagePrefix":
+		g.ImagePrefix = value
+	default:
+		return unknownOption(g.ext, key)
+	}
+	return nil
+}
+
 func (g *GodotExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
 	if len(atlasInfo.Atlases) == 0 {
 		return nil, fmt.Errorf("no Atlas found")
 	}
@@ -62,7 +77,7 @@ func (g *GodotExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
 	data := godotTemplateData{
 		Meta: atlasInfo.Meta,
 		Atlas: gdAtlas{
-			FileName: atlas.Name,
+			FileName: g.ImagePrefix + atlas.Name,
 			Width:    atlas.Size.W,
 			Height:   atlas.Size.H,
 		},
@@ -78,6 +93,14 @@ func (g *GodotExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+// Detect recognises json with a "textures" list.
+func (g *GodotExporter) Detect(data []byte) bool {
+	var raw struct {
+		Textures []json.RawMessage `json:"textures"`
+	}
+	return json.Unmarshal(data, &raw) == nil && raw.Textures != nil
+}
+
 // Import TODO test
 func (g *GodotExporter) Import(data []byte) (*model.AtlasInfo, error) {
 	var raw struct {
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"go/format"
+	"go/token"
+	"image"
+	"path/filepath"
+	"strconv"
+	"text/template"
+)
+
+type goAtlas struct {
+	Ident string
+	Name  string
+}
+
+type goSprite struct {
+	Ident   string
+	Name    string
+	Atlas   int
+	Frame   image.Rectangle
+	Offset  model.Point
+	Source  model.Size
+	Rotated bool
+}
+
+type goTemplateData struct {
+	Meta    model.Meta
+	Package string
+	Embed   bool
+	Atlases []goAtlas
+	Sprites []goSprite
+}
+
+// GoExporter exports a go source file declaring one variable per sprite,
+// so sprite lookups are checked by the compiler, e.g. for Ebitengine games.
+type GoExporter struct {
+	ext string
+	// Package is the package name of the generated file, "atlas" by default.
+	Package string
+	// Embed adds a //go:embed variable for every atlas image,
+	// the images must be in the same directory as the generated file.
+	Embed bool
+}
+
+func (g *GoExporter) Ext() string {
+	return g.ext
+}
+func (g *GoExporter) SetExt(ext string) {
+	g.ext = ext
+}
+
+func (g *GoExporter) SetOption(key, value string) error {
+	switch key {
+	case "package":
+		g.Package = value
+	case "embed":
+		embed, err := parseBoolOption(key, value)
+		if err != nil {
+			return err
+		}
+		g.Embed = embed
+	default:
+		return unknownOption(g.ext, key)
+	}
+	return nil
+}
+
+func (g *GoExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	pkg := g.Package
+	if pkg == "" {
+		pkg = "atlas"
+	}
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	data := goTemplateData{
+		Meta:    atlasInfo.Meta,
+		Package: pkg,
+		Embed:   g.Embed,
+	}
+	namer := newIdentNamer()
+	// the names are the keys of the Sprites map
+	names := make(map[string]bool)
+	// reserve the declarations of the template
+	for _, ident := range []string{"Sprite", "Sprites", "Atlases"} {
+		namer.name("", ident, "")
+	}
+	for i, atlas := range atlasInfo.Atlases {
+		data.Atlases = append(data.Atlases, goAtlas{
+			Ident: namer.name("", pascalCase(trimExt(atlas.Name))+"Image", "Image"),
+			Name:  strconv.Quote(filepath.ToSlash(atlas.Name)),
+		})
+		for j, sprite := range atlas.Sprites {
+			if names[sprite.FileName] {
+				return nil, fmt.Errorf("duplicate sprite name %s, the names must be unique across the atlases", sprite.FileName)
+			}
+			names[sprite.FileName] = true
+			ident := pascalCase(trimExt(sprite.FileName))
+			prefix := ""
+			if ident == "" || ident[0] >= '0' && ident[0] <= '9' {
+				prefix = "Sprite"
+			}
+			s := goSprite{
+				Ident:   namer.name(prefix, ident, strconv.Itoa(j)),
+				Name:    strconv.Quote(sprite.FileName),
+				Atlas:   i,
+				Frame:   sprite.Frame.ToImageRect(),
+				Offset:  spriteOffset(sprite),
+				Source:  sprite.SrcRect,
+				Rotated: sprite.Rotated,
+			}
+			data.Sprites = append(data.Sprites, s)
+		}
+	}
+
+	tmpl, err := template.New(g.Ext()).Parse(goTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+func (g *GoExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	return nil, errors.New("go source format does not support import")
+}
+
+const goTemplate = `// Code generated by spritepacker {{.Meta.Version}}; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	{{- if .Embed}}
+	_ "embed"
+	{{- end}}
+	"image"
+)
+
+// Sprite is a sprite packed in one of the Atlases.
+type Sprite struct {
+	Name string
+	// Atlas is the index of the atlas in Atlases.
+	Atlas int
+	// Frame is the region of the sprite in the atlas image.
+	Frame image.Rectangle
+	// Offset is the position of the trimmed sprite in the source image.
+	Offset image.Point
+	// Source is the size of the source image.
+	Source image.Point
+	// Rotated means the sprite is stored rotated 90 degrees clockwise.
+	Rotated bool
+}
+
+// Atlases are the atlas image names.
+var Atlases = []string{
+	{{- range .Atlases}}
+	{{.Name}},
+	{{- end}}
+}
+{{- if .Embed}}
+{{range .Atlases}}
+//go:embed {{.Name}}
+var {{.Ident}} []byte
+{{end}}
+{{- end}}
+
+var (
+	{{- range .Sprites}}
+	{{.Ident}} = Sprite{Name: {{.Name}}, Atlas: {{.Atlas}}, Frame: image.Rect({{.Frame.Min.X}}, {{.Frame.Min.Y}}, {{.Frame.Max.X}}, {{.Frame.Max.Y}}), Offset: image.Pt({{.Offset.X}}, {{.Offset.Y}}), Source: image.Pt({{.Source.W}}, {{.Source.H}}), Rotated: {{.Rotated}}}
+	{{- end}}
+)
+
+// Sprites maps the sprite file names to the sprites.
+var Sprites = map[string]Sprite{
+	{{- range .Sprites}}
+	{{.Name}}: {{.Ident}},
+	{{- end}}
+}
+`
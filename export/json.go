@@ -7,6 +7,11 @@ import (
 
 type JsonExporter struct {
 	ext string
+	// Compact disables the indentation.
+	Compact bool
+	// TexturePacker exports the TexturePacker json array format with the sprite polygons instead,
+	// one file per atlas.
+	TexturePacker bool
 }
 
 func (j *JsonExporter) Ext() string {
@@ -16,12 +21,66 @@ func (j *JsonExporter) SetExt(ext string) {
 	j.ext = ext
 }
 
+func (j *JsonExporter) Polygons() bool {
+	return true
+}
+
+func (j *JsonExporter) PerAtlas() bool {
+	return j.TexturePacker
+}
+
+func (j *JsonExporter) SetOption(key, value string) error {
+	switch key {
+	case "compact":
+		compact, err := parseBoolOption(key, value)
+		if err != nil {
+			return err
+		}
+		j.Compact = compact
+	case "texturePacker":
+		texturePacker, err := parseBoolOption(key, value)
+		if err != nil {
+			return err
+		}
+		j.TexturePacker = texturePacker
+	default:
+		return unknownOption(j.ext, key)
+	}
+	return nil
+}
+
 func (j *JsonExporter) Export(atlas *model.AtlasInfo) ([]byte, error) {
+	if j.TexturePacker {
+		return exportTexturePacker(atlas, j.Compact)
+	}
+	if j.Compact {
+		return json.Marshal(atlas)
+	}
 	return json.MarshalIndent(atlas, "", "    ")
 }
 
+// Detect recognises json with an "atlases" list, the TexturePacker json or the Aseprite json sheet.
+func (j *JsonExporter) Detect(data []byte) bool {
+	return hasAtlases(data) || isTexturePacker(data) || isAseprite(data)
+}
+
 func (j *JsonExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	if !hasAtlases(data) {
+		if isAseprite(data) {
+			return importAseprite(data)
+		}
+		if isTexturePacker(data) {
+			return importTexturePacker(data)
+		}
+	}
 	var atlas model.AtlasInfo
 	err := json.Unmarshal(data, &atlas)
 	return &atlas, err
 }
+
+func hasAtlases(data []byte) bool {
+	var raw struct {
+		Atlases []json.RawMessage `json:"atlases"`
+	}
+	return json.Unmarshal(data, &raw) == nil && raw.Atlases != nil
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"path/filepath"
+	"text/template"
+)
+
+type luaSprite struct {
+	Name    string
+	Atlas   int // 1-based index in atlases
+	Frame   model.Rect
+	Offset  model.Point
+	Source  model.Size
+	Pivot   *model.Pivot
+	Rotated bool
+}
+
+type luaTemplateData struct {
+	Meta    model.Meta
+	Atlases []model.Atlas
+	Sprites []luaSprite
+}
+
+// LuaExporter exports a Lua module returning a table of the atlases and of the sprites keyed by name,
+// e.g. for LÖVE: love.graphics.newQuad(s.x, s.y, s.w, s.h, atlas.width, atlas.height).
+type LuaExporter struct {
+	ext string
+}
+
+func (l *LuaExporter) Ext() string {
+	return l.ext
+}
+func (l *LuaExporter) SetExt(ext string) {
+	l.ext = ext
+}
+
+func (l *LuaExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	data := luaTemplateData{Meta: atlasInfo.Meta}
+	names := make(map[string]bool)
+	for i, atlas := range atlasInfo.Atlases {
+		atlas.Name = filepath.ToSlash(atlas.Name)
+		data.Atlases = append(data.Atlases, atlas)
+		for _, sprite := range atlas.Sprites {
+			if names[sprite.FileName] {
+				return nil, fmt.Errorf("duplicate sprite name %s, the names must be unique across the atlases", sprite.FileName)
+			}
+			names[sprite.FileName] = true
+			data.Sprites = append(data.Sprites, luaSprite{
+				Name:    sprite.FileName,
+				Atlas:   i + 1,
+				Frame:   sprite.Frame,
+				Offset:  spriteOffset(sprite),
+				Source:  sprite.SrcRect,
+				Pivot:   sprite.Pivot,
+				Rotated: sprite.Rotated,
+			})
+		}
+	}
+
+	tmpl, err := template.New(l.Ext()).Funcs(TemplateFuncs()).Parse(luaTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+func (l *LuaExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	return nil, errors.New("lua format does not support import")
+}
+
+const luaTemplate = `-- Generated by spritepacker {{luaEscape .Meta.Version}}, do not edit.
+-- x, y, w, h: region in the atlas image
+-- offsetX, offsetY: position of the trimmed sprite in the source image
+-- sourceW, sourceH: size of the source image
+-- rotated: stored rotated 90 degrees clockwise
+return {
+	atlases = {
+	{{- range .Atlases}}
+		{ image = "{{luaEscape .Name}}", width = {{.Size.W}}, height = {{.Size.H}} },
+	{{- end}}
+	},
+	sprites = {
+	{{- range .Sprites}}
+		["{{luaEscape .Name}}"] = {
+			atlas = {{.Atlas}},
+			x = {{.Frame.X}}, y = {{.Frame.Y}}, w = {{.Frame.W}}, h = {{.Frame.H}},
+			offsetX = {{.Offset.X}}, offsetY = {{.Offset.Y}},
+			sourceW = {{.Source.W}}, sourceH = {{.Source.H}},
+			{{- with .Pivot}}
+			pivotX = {{.X}}, pivotY = {{.Y}},
+			{{- end}}
+			rotated = {{.Rotated}},
+		},
+	{{- end}}
+	},
+}
+`
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var wordRegex = regexp.MustCompile(`[A-Z]+[a-z0-9]*|[a-z0-9]+`)
+
+// trimExt returns the name without its extension, e.g. "player/run_01.png" -> "player/run_01".
+func trimExt(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// words splits a name into ascii words, e.g. "player/runLeft_01" -> [player run Left 01].
+func words(name string) []string {
+	return wordRegex.FindAllString(name, -1)
+}
+
+// pascalCase converts a name into an exported identifier, e.g. "run_01" -> "Run01".
+func pascalCase(name string) string {
+	var sb strings.Builder
+	for _, w := range words(name) {
+		sb.WriteString(strings.ToUpper(w[:1]) + strings.ToLower(w[1:]))
+	}
+	return sb.String()
+}
+
+// camelCase converts a name into an unexported identifier, e.g. "run_01" -> "run01".
+func camelCase(name string) string {
+	ident := pascalCase(name)
+	if ident == "" {
+		return ident
+	}
+	return strings.ToLower(ident[:1]) + ident[1:]
+}
+
+// snakeCase converts a name into a snake case identifier, e.g. "runLeft_01" -> "run_left_01".
+func snakeCase(name string) string {
+	return strings.ToLower(strings.Join(words(name), "_"))
+}
+
+// upperSnakeCase converts a name into a constant identifier, e.g. "run_01" -> "RUN_01".
+func upperSnakeCase(name string) string {
+	return strings.ToUpper(strings.Join(words(name), "_"))
+}
+
+// identNamer generates unique identifiers, appending a number to repeated names.
+type identNamer struct {
+	used map[string]bool
+}
+
+func newIdentNamer() *identNamer {
+	return &identNamer{used: make(map[string]bool)}
+}
+
+// name returns prefix+ident, or prefix+fallback if ident is empty.
+// The prefix may be empty as long as ident does not start with a digit.
+func (n *identNamer) name(prefix, ident, fallback string) string {
+	if ident == "" {
+		ident = fallback
+	}
+	ident = prefix + ident
+	if ident[0] >= '0' && ident[0] <= '9' {
+		ident = "_" + ident
+	}
+	result := ident
+	for i := 2; n.used[result]; i++ {
+		result = ident + "_" + strconv.Itoa(i)
+	}
+	n.used[result] = true
+	return result
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"strconv"
+	"strings"
+)
+
+// Configurable is implemented by exporters accepting key-value options,
+// the same options are available as typed fields on the exporters.
+type Configurable interface {
+	SetOption(key, value string) error
+}
+
+// OptStripExt is handled by ExporterManager for every exporter:
+// sprite names are exported without their file extension.
+const OptStripExt = "stripExt"
+
+// SetOption sets an option of the exporter registered for ext.
+//
+// Options:
+//   - stripExt (all): export sprite names without their file extension
+//   - compact, texturePacker (.json): no indentation, TexturePacker json array format with the polygons
+//   - imagePrefix (.tpsheet): prefix of the image path, e.g. "res://sprites/"
+//   - scale, prefix (.css, .html): pixel ratio of the atlas images and class name prefix
+//   - pixelsPerUnit (.meta): pixels per unit of the unity texture
+//   - package, embed (.go): package name and whether to embed the atlas images
+//   - prefix, guard (.h): enum prefix and include guard
+//   - imagePrefix (.defold.atlas): project path of the sprite images, e.g. "/sprites/"
+//
+// Example:
+//
+//	err := manager.SetOption(".json", "compact", "true")
+func (m *ExporterManager) SetOption(ext, key, value string) error {
+	ext = strings.ToLower(ext)
+	exporter, ok := m.exporters[ext]
+	if !ok {
+		return fmt.Errorf("unsupported file type %s", ext)
+	}
+	if key == OptStripExt {
+		strip, err := parseBoolOption(key, value)
+		if err != nil {
+			return err
+		}
+		m.stripExt[ext] = strip
+		return nil
+	}
+	if c, ok := exporter.(Configurable); ok {
+		return c.SetOption(key, value)
+	}
+	return unknownOption(ext, key)
+}
+
+// Get returns the exporter registered for ext, to set its typed options.
+func (m *ExporterManager) Get(ext string) (Exporter, bool) {
+	exporter, ok := m.exporters[strings.ToLower(ext)]
+	return exporter, ok
+}
+
+// Polygons reports whether the exporter registered for ext carries the sprite polygons.
+func (m *ExporterManager) Polygons(ext string) bool {
+	exporter, ok := m.Get(ext)
+	if !ok {
+		return false
+	}
+	p, ok := exporter.(PolygonExporter)
+	return ok && p.Polygons()
+}
+
+// withoutSpriteExt returns a copy of the atlas info whose sprite names have no file extension.
+func withoutSpriteExt(atlasInfo *model.AtlasInfo) *model.AtlasInfo {
+	result := &model.AtlasInfo{
+		Meta:    atlasInfo.Meta,
+		Atlases: make([]model.Atlas, len(atlasInfo.Atlases)),
+	}
+	for i, atlas := range atlasInfo.Atlases {
+		sprites := make([]model.Sprite, len(atlas.Sprites))
+		for j, sprite := range atlas.Sprites {
+			sprites[j] = sprite.Clone()
+			sprites[j].FileName = trimExt(sprite.FileName)
+			if sprite.AliasOf != "" {
+				sprites[j].AliasOf = trimExt(sprite.AliasOf)
+			}
+		}
+		atlas.Sprites = sprites
+		result.Atlases[i] = atlas
+	}
+	for _, animation := range atlasInfo.Animations {
+		frames := make([]string, len(animation.Frames))
+		for i, frame := range animation.Frames {
+			frames[i] = trimExt(frame)
+		}
+		animation.Frames = frames
+		result.Animations = append(result.Animations, animation)
+	}
+	return result
+}
+
+func unknownOption(ext, key string) error {
+	return fmt.Errorf("unknown option %q for %s", key, ext)
+}
+
+func parseBoolOption(key, value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("option %s: %q is not a bool", key, value)
+	}
+	return b, nil
+}
+
+func parseFloatOption(key, value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("option %s: %q is not a number", key, value)
+	}
+	return f, nil
+}
@@ -0,0 +1,395 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"github.com/91xusir/spritepacker/utils"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type plistFrame struct {
+	Name        string
+	Offset      string
+	Size        string
+	SourceSize  string
+	TextureRect string
+	Rotated     bool
+	Aliases     []string
+}
+
+type plistTemplateData struct {
+	Meta   model.Meta
+	Image  string
+	Size   string
+	Frames []plistFrame
+}
+
+// PlistExporter exports the Cocos2d-x property list format (format 3).
+// The format describes a single atlas, so one file is written per atlas.
+type PlistExporter struct {
+	ext string
+}
+
+func (p *PlistExporter) Ext() string {
+	return p.ext
+}
+func (p *PlistExporter) SetExt(ext string) {
+	p.ext = ext
+}
+func (p *PlistExporter) PerAtlas() bool {
+	return true
+}
+
+func (p *PlistExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	atlas := atlasInfo.Atlases[0]
+	frames := make([]plistFrame, 0, len(atlas.Sprites))
+	// index of the frames by sprite name, to list the aliases in the frame of their sprite
+	index := make(map[string]int)
+	var aliases []model.Sprite
+	for _, sprite := range atlas.Sprites {
+		if sprite.AliasOf != "" {
+			aliases = append(aliases, sprite)
+			continue
+		}
+		index[sprite.FileName] = len(frames)
+		frames = append(frames, newPlistFrame(sprite))
+	}
+	for _, alias := range aliases {
+		// cocos aliases share the whole frame, differently trimmed aliases are frames of their own
+		if i, ok := index[alias.AliasOf]; ok && frames[i].sameGeometry(newPlistFrame(alias)) {
+			frames[i].Aliases = append(frames[i].Aliases, alias.FileName)
+			continue
+		}
+		frames = append(frames, newPlistFrame(alias))
+	}
+	data := plistTemplateData{
+		Meta:   atlasInfo.Meta,
+		Image:  atlas.Name,
+		Size:   fmt.Sprintf("{%d,%d}", atlas.Size.W, atlas.Size.H),
+		Frames: frames,
+	}
+
+	tmpl, err := template.New(p.Ext()).Parse(plistTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+func newPlistFrame(sprite model.Sprite) plistFrame {
+	// cocos stores the unrotated size of the trimmed sprite
+	size := spriteSize(sprite)
+	// spriteOffset is the distance between the center of the trimmed rect
+	// and the center of the source image, with the y-axis pointing up
+	offset := spriteCenterOffset(sprite)
+	return plistFrame{
+		Name:        sprite.FileName,
+		Offset:      fmt.Sprintf("{%s,%s}", formatFloat(offset.X), formatFloat(offset.Y)),
+		Size:        fmt.Sprintf("{%d,%d}", size.W, size.H),
+		SourceSize:  fmt.Sprintf("{%d,%d}", sprite.SrcRect.W, sprite.SrcRect.H),
+		TextureRect: fmt.Sprintf("{{%d,%d},{%d,%d}}", sprite.Frame.X, sprite.Frame.Y, size.W, size.H),
+		Rotated:     sprite.Rotated,
+	}
+}
+
+func (f plistFrame) sameGeometry(other plistFrame) bool {
+	return f.Offset == other.Offset && f.Size == other.Size && f.SourceSize == other.SourceSize &&
+		f.TextureRect == other.TextureRect && f.Rotated == other.Rotated
+}
+
+// Detect recognises property lists with a "frames" dict.
+func (p *PlistExporter) Detect(data []byte) bool {
+	if !bytes.Contains(data, []byte("<plist")) {
+		return false
+	}
+	root, err := decodePlist(data)
+	if err != nil {
+		return false
+	}
+	dict, ok := root.(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = dict["frames"].(map[string]any)
+	return ok
+}
+
+func (p *PlistExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	root, err := decodePlist(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]any)
+	if !ok {
+		return nil, errors.New("plist root is not a dict")
+	}
+	frames, ok := dict["frames"].(map[string]any)
+	if !ok {
+		return nil, errors.New("no frames found")
+	}
+	metadata, _ := dict["metadata"].(map[string]any)
+
+	// dict keys are unordered, keep the sprites in natural order
+	names := make([]string, 0, len(frames))
+	for name := range frames {
+		names = append(names, name)
+	}
+	utils.NaturalSort(names)
+
+	sprites := make([]model.Sprite, 0, len(frames))
+	for _, name := range names {
+		frame, ok := frames[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("frame %s is not a dict", name)
+		}
+		textureRect, err := parsePlistNumbers(frame["textureRect"], 4)
+		if err != nil {
+			return nil, fmt.Errorf("frame %s: textureRect: %v", name, err)
+		}
+		sourceSize, err := parsePlistNumbers(frame["spriteSourceSize"], 2)
+		if err != nil {
+			return nil, fmt.Errorf("frame %s: spriteSourceSize: %v", name, err)
+		}
+		offset, err := parsePlistNumbers(frame["spriteOffset"], 2)
+		if err != nil {
+			return nil, fmt.Errorf("frame %s: spriteOffset: %v", name, err)
+		}
+		rotated, _ := frame["textureRotated"].(bool)
+
+		x, y := int(textureRect[0]), int(textureRect[1])
+		w, h := int(textureRect[2]), int(textureRect[3])
+		srcW, srcH := int(sourceSize[0]), int(sourceSize[1])
+		trimmed := w != srcW || h != srcH || offset[0] != 0 || offset[1] != 0
+
+		trimmedRect := model.Rect{}
+		if trimmed {
+			trimX := int(math.Round(float64(srcW-w)/2 + offset[0]))
+			trimY := int(math.Round(float64(srcH-h)/2 - offset[1]))
+			trimmedRect = model.NewRectByPosAndSize(trimX, trimY, w, h)
+		}
+		frameRect := model.NewRectByPosAndSize(x, y, w, h)
+		if rotated {
+			frameRect = frameRect.Rotated()
+		}
+		sprite := model.Sprite{
+			FileName:    name,
+			Frame:       frameRect,
+			SrcRect:     model.Size{W: srcW, H: srcH},
+			TrimmedRect: trimmedRect,
+			Rotated:     rotated,
+			Trimmed:     trimmed,
+		}
+		sprites = append(sprites, sprite)
+		aliases, _ := frame["aliases"].([]any)
+		for _, alias := range aliases {
+			if aliasName, ok := alias.(string); ok && aliasName != "" {
+				s := sprite.Clone()
+				s.FileName = aliasName
+				s.AliasOf = name
+				sprites = append(sprites, s)
+			}
+		}
+	}
+
+	atlas := model.Atlas{Sprites: sprites}
+	meta := model.Meta{Format: "plist"}
+	if metadata != nil {
+		if name, ok := metadata["textureFileName"].(string); ok {
+			atlas.Name = name
+		}
+		if size, err := parsePlistNumbers(metadata["size"], 2); err == nil {
+			atlas.Size = model.Size{W: int(size[0]), H: int(size[1])}
+		}
+		if pixelFormat, ok := metadata["pixelFormat"].(string); ok {
+			meta.Format = pixelFormat
+		}
+	}
+	return &model.AtlasInfo{
+		Meta:    meta,
+		Atlases: []model.Atlas{atlas},
+	}, nil
+}
+
+// parsePlistNumbers parses cocos geometry strings like "{1,2}" or "{{1,2},{3,4}}".
+func parsePlistNumbers(v any, count int) ([]float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("missing value")
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '{' || r == '}' || r == ',' || r == ' '
+	})
+	if len(fields) != count {
+		return nil, fmt.Errorf("expected %d numbers, got %q", count, s)
+	}
+	numbers := make([]float64, count)
+	for i, field := range fields {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// decodePlist decodes an XML property list into dicts (map[string]any), arrays ([]any),
+// strings, integers (int64), reals (float64) and booleans.
+func decodePlist(data []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("empty plist")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local == "plist" {
+				continue
+			}
+			return decodePlistValue(decoder, start)
+		}
+	}
+}
+
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []any
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	switch start.Name.Local {
+	case "integer":
+		return strconv.ParseInt(text, 10, 64)
+	case "real":
+		return strconv.ParseFloat(text, 64)
+	default:
+		// string, date and data are kept as text
+		return text, nil
+	}
+}
+
+const plistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+	<dict>
+		<key>frames</key>
+		<dict>
+			{{- range .Frames}}
+			<key>{{html .Name}}</key>
+			<dict>
+				<key>aliases</key>
+				{{- if .Aliases}}
+				<array>
+					{{- range .Aliases}}
+					<string>{{html .}}</string>
+					{{- end}}
+				</array>
+				{{- else}}
+				<array/>
+				{{- end}}
+				<key>spriteOffset</key>
+				<string>{{.Offset}}</string>
+				<key>spriteSize</key>
+				<string>{{.Size}}</string>
+				<key>spriteSourceSize</key>
+				<string>{{.SourceSize}}</string>
+				<key>textureRect</key>
+				<string>{{.TextureRect}}</string>
+				<key>textureRotated</key>
+				{{if .Rotated}}<true/>{{else}}<false/>{{end}}
+			</dict>
+			{{- end}}
+		</dict>
+		<key>metadata</key>
+		<dict>
+			<key>format</key>
+			<integer>3</integer>
+			<key>pixelFormat</key>
+			<string>{{html .Meta.Format}}</string>
+			<key>premultiplyAlpha</key>
+			<false/>
+			<key>realTextureFileName</key>
+			<string>{{html .Image}}</string>
+			<key>size</key>
+			<string>{{.Size}}</string>
+			<key>smartupdate</key>
+			<string>{{html .Meta.Timestamp}}</string>
+			<key>textureFileName</key>
+			<string>{{html .Image}}</string>
+		</dict>
+	</dict>
+</plist>
+`
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+)
+
+type sparrowAtlas struct {
+	XMLName     xml.Name            `xml:"TextureAtlas"`
+	ImagePath   string              `xml:"imagePath,attr"`
+	Width       int                 `xml:"width,attr,omitempty"`
+	Height      int                 `xml:"height,attr,omitempty"`
+	SubTextures []sparrowSubTexture `xml:"SubTexture"`
+}
+
+// sparrowSubTexture x, y, width and height describe the region in the atlas,
+// frame* describe the position of the region inside the untrimmed sprite.
+type sparrowSubTexture struct {
+	Name        string `xml:"name,attr"`
+	X           int    `xml:"x,attr"`
+	Y           int    `xml:"y,attr"`
+	Width       int    `xml:"width,attr"`
+	Height      int    `xml:"height,attr"`
+	FrameX      int    `xml:"frameX,attr,omitempty"`
+	FrameY      int    `xml:"frameY,attr,omitempty"`
+	FrameWidth  int    `xml:"frameWidth,attr,omitempty"`
+	FrameHeight int    `xml:"frameHeight,attr,omitempty"`
+	Rotated     bool   `xml:"rotated,attr,omitempty"`
+}
+
+// SparrowExporter exports the Sparrow/Starling TextureAtlas XML format.
+// The format describes a single atlas, so one file is written per atlas.
+type SparrowExporter struct {
+	ext string
+}
+
+func (s *SparrowExporter) Ext() string {
+	return s.ext
+}
+func (s *SparrowExporter) SetExt(ext string) {
+	s.ext = ext
+}
+func (s *SparrowExporter) PerAtlas() bool {
+	return true
+}
+
+func (s *SparrowExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	atlas := atlasInfo.Atlases[0]
+	raw := sparrowAtlas{
+		ImagePath:   atlas.Name,
+		Width:       atlas.Size.W,
+		Height:      atlas.Size.H,
+		SubTextures: make([]sparrowSubTexture, len(atlas.Sprites)),
+	}
+	for i, sprite := range atlas.Sprites {
+		sub := sparrowSubTexture{
+			Name:    sprite.FileName,
+			X:       sprite.Frame.X,
+			Y:       sprite.Frame.Y,
+			Width:   sprite.Frame.W,
+			Height:  sprite.Frame.H,
+			Rotated: sprite.Rotated,
+		}
+		if sprite.Trimmed {
+			sub.FrameX = -sprite.TrimmedRect.X
+			sub.FrameY = -sprite.TrimmedRect.Y
+			sub.FrameWidth = sprite.SrcRect.W
+			sub.FrameHeight = sprite.SrcRect.H
+		}
+		raw.SubTextures[i] = sub
+	}
+	data, err := xml.MarshalIndent(raw, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Detect recognises xml whose root element is TextureAtlas.
+func (s *SparrowExporter) Detect(data []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "TextureAtlas"
+		}
+	}
+}
+
+func (s *SparrowExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	var raw sparrowAtlas
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	sprites := make([]model.Sprite, len(raw.SubTextures))
+	for i, sub := range raw.SubTextures {
+		frame := model.NewRectByPosAndSize(sub.X, sub.Y, sub.Width, sub.Height)
+		frame.IsRotated = sub.Rotated
+		// the trimmed size is the unrotated size of the region
+		size := frame.Size
+		if sub.Rotated {
+			size = size.Rotated()
+		}
+		trimmed := sub.FrameWidth != 0 && sub.FrameHeight != 0
+		srcRect := size
+		trimmedRect := model.Rect{}
+		if trimmed {
+			srcRect = model.Size{W: sub.FrameWidth, H: sub.FrameHeight}
+			trimmedRect = model.NewRectByPosAndSize(-sub.FrameX, -sub.FrameY, size.W, size.H)
+		}
+		sprites[i] = model.Sprite{
+			FileName:    sub.Name,
+			Frame:       frame,
+			SrcRect:     srcRect,
+			TrimmedRect: trimmedRect,
+			Rotated:     sub.Rotated,
+			Trimmed:     trimmed,
+		}
+	}
+	return &model.AtlasInfo{
+		Meta: model.Meta{
+			Format: "xml",
+		},
+		Atlases: []model.Atlas{
+			{
+				Name:    raw.ImagePath,
+				Size:    model.Size{W: raw.Width, H: raw.Height},
+				Sprites: sprites,
+			},
+		},
+	}, nil
+}
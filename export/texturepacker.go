@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"strings"
+)
+
+// tpFrame is a frame of the TexturePacker json, the rect layout is the one of the aseprite json.
+type tpFrame struct {
+	FileName         string       `json:"filename"`
+	Frame            asepriteRect `json:"frame"`
+	Rotated          bool         `json:"rotated"`
+	Trimmed          bool         `json:"trimmed"`
+	SpriteSourceSize asepriteRect `json:"spriteSourceSize"`
+	SourceSize       model.Size   `json:"sourceSize"`
+	Pivot            *model.Pivot `json:"pivot,omitempty"`
+	Vertices         [][2]int     `json:"vertices,omitempty"`
+	VerticesUV       [][2]int     `json:"verticesUV,omitempty"`
+	Triangles        [][3]int     `json:"triangles,omitempty"`
+}
+
+type tpMeta struct {
+	App         string     `json:"app"`
+	Version     string     `json:"version"`
+	Image       string     `json:"image"`
+	Format      string     `json:"format"`
+	Size        model.Size `json:"size"`
+	Scale       string     `json:"scale"`
+	SmartUpdate string     `json:"smartupdate,omitempty"`
+}
+
+type tpSheet struct {
+	Frames json.RawMessage `json:"frames"`
+	Meta   tpMeta          `json:"meta"`
+}
+
+// exportTexturePacker exports the first atlas in the TexturePacker json array format, with the polygon
+// vertices in the source sprite, in the atlas (verticesUV) and the triangles of the sprites which have one.
+// The frame size is the size of the sprite before rotation.
+func exportTexturePacker(atlasInfo *model.AtlasInfo, compact bool) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	atlas := atlasInfo.Atlases[0]
+	frames := make([]tpFrame, len(atlas.Sprites))
+	for i, sprite := range atlas.Sprites {
+		size := spriteSize(sprite)
+		offset := spriteOffset(sprite)
+		frame := tpFrame{
+			FileName:         sprite.FileName,
+			Frame:            asepriteRect{X: sprite.Frame.X, Y: sprite.Frame.Y, W: size.W, H: size.H},
+			Rotated:          sprite.Rotated,
+			Trimmed:          sprite.Trimmed,
+			SpriteSourceSize: asepriteRect{X: offset.X, Y: offset.Y, W: size.W, H: size.H},
+			SourceSize:       sprite.SrcRect,
+			Pivot:            sprite.Pivot,
+			Triangles:        sprite.Triangles,
+		}
+		for _, v := range sprite.Vertices {
+			frame.Vertices = append(frame.Vertices, [2]int{v.X, v.Y})
+		}
+		for _, v := range spriteVerticesUV(sprite) {
+			frame.VerticesUV = append(frame.VerticesUV, [2]int{v.X, v.Y})
+		}
+		frames[i] = frame
+	}
+	data, err := json.Marshal(frames)
+	if err != nil {
+		return nil, err
+	}
+	sheet := tpSheet{
+		Frames: data,
+		Meta: tpMeta{
+			App:         atlasInfo.Meta.Repo,
+			Version:     atlasInfo.Meta.Version,
+			Image:       atlas.Name,
+			Format:      atlasInfo.Meta.Format,
+			Size:        atlas.Size,
+			Scale:       "1",
+			SmartUpdate: atlasInfo.Meta.Timestamp,
+		},
+	}
+	if compact {
+		return json.Marshal(sheet)
+	}
+	return json.MarshalIndent(sheet, "", "    ")
+}
+
+// isTexturePacker recognises the TexturePacker json, with frames and a meta image which is not from aseprite.
+func isTexturePacker(data []byte) bool {
+	var sheet tpSheet
+	return json.Unmarshal(data, &sheet) == nil && len(sheet.Frames) > 0 && sheet.Meta.Image != "" &&
+		!strings.Contains(strings.ToLower(sheet.Meta.App), "aseprite")
+}
+
+// importTexturePacker imports the TexturePacker json in the hash or array layout, with the polygons of the sprites.
+func importTexturePacker(data []byte) (*model.AtlasInfo, error) {
+	var sheet tpSheet
+	if err := json.Unmarshal(data, &sheet); err != nil {
+		return nil, err
+	}
+	frames, err := decodeFrames(sheet.Frames, func(f *tpFrame, name string) { f.FileName = name })
+	if err != nil {
+		return nil, err
+	}
+	sprites := make([]model.Sprite, len(frames))
+	for i, f := range frames {
+		frame := model.NewRectByPosAndSize(f.Frame.X, f.Frame.Y, f.Frame.W, f.Frame.H)
+		if f.Rotated {
+			frame = frame.Rotated()
+		}
+		sprite := model.Sprite{
+			FileName:  f.FileName,
+			Frame:     frame,
+			SrcRect:   f.SourceSize,
+			Rotated:   f.Rotated,
+			Trimmed:   f.Trimmed,
+			Pivot:     f.Pivot,
+			Triangles: f.Triangles,
+		}
+		if sprite.SrcRect.W == 0 || sprite.SrcRect.H == 0 {
+			sprite.SrcRect = model.Size{W: f.Frame.W, H: f.Frame.H}
+		}
+		if f.Trimmed {
+			s := f.SpriteSourceSize
+			sprite.TrimmedRect = model.NewRectByPosAndSize(s.X, s.Y, s.W, s.H)
+		}
+		for _, v := range f.Vertices {
+			sprite.Vertices = append(sprite.Vertices, model.Point{X: v[0], Y: v[1]})
+		}
+		sprites[i] = sprite
+	}
+	return &model.AtlasInfo{
+		Meta: model.Meta{
+			Repo:      sheet.Meta.App,
+			Format:    sheet.Meta.Format,
+			Version:   sheet.Meta.Version,
+			Timestamp: sheet.Meta.SmartUpdate,
+		},
+		Atlases: []model.Atlas{
+			{
+				Name:    sheet.Meta.Image,
+				Size:    sheet.Meta.Size,
+				Sprites: sprites,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"bytes"
+	"github.com/91xusir/spritepacker/model"
+	"github.com/BurntSushi/toml"
+)
+
+// TomlExporter exports model.AtlasInfo as toml, with the same schema as JsonExporter.
+type TomlExporter struct {
+	ext string
+}
+
+func (t *TomlExporter) Ext() string {
+	return t.ext
+}
+func (t *TomlExporter) SetExt(ext string) {
+	t.ext = ext
+}
+
+func (t *TomlExporter) Polygons() bool {
+	return true
+}
+
+func (t *TomlExporter) Export(atlas *model.AtlasInfo) ([]byte, error) {
+	var buf bytes.Buffer
+	err := toml.NewEncoder(&buf).Encode(atlas)
+	return buf.Bytes(), err
+}
+
+// Detect recognises toml with an "atlases" array.
+func (t *TomlExporter) Detect(data []byte) bool {
+	var raw struct {
+		Atlases []toml.Primitive `toml:"atlases"`
+	}
+	_, err := toml.Decode(string(data), &raw)
+	return err == nil && raw.Atlases != nil
+}
+
+func (t *TomlExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	var atlas model.AtlasInfo
+	_, err := toml.Decode(string(data), &atlas)
+	return &atlas, err
+}
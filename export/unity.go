@@ -0,0 +1,226 @@
+package export
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type unitySprite struct {
+	Name       string
+	X, Y, W, H int
+	Alignment  int
+	PivotX     string
+	PivotY     string
+	Border     model.Border
+	SpriteID   string
+	InternalID int64
+}
+
+type unityTemplateData struct {
+	Guid           string
+	MaxTextureSize int
+	PixelsPerUnit  string
+	Sprites        []unitySprite
+}
+
+// UnityExporter exports the Unity TextureImporter .meta file of the atlas image,
+// slicing it into multiple sprites. Unity can not describe rotated sprites.
+type UnityExporter struct {
+	ext string
+	// PixelsPerUnit is the spritePixelsToUnits of the texture, 100 by default.
+	PixelsPerUnit float64
+}
+
+func (u *UnityExporter) Ext() string {
+	return u.ext
+}
+func (u *UnityExporter) SetExt(ext string) {
+	u.ext = ext
+}
+func (u *UnityExporter) PerAtlas() bool {
+	return true
+}
+
+// AtlasFileName unity expects the meta file next to the texture, e.g. atlas.png.meta
+func (u *UnityExporter) AtlasFileName(atlas model.Atlas) string {
+	return filepath.Base(atlas.Name) + ".meta"
+}
+
+func (u *UnityExporter) SetOption(key, value string) error {
+	switch key {
+	case "pixelsPerUnit":
+		pixelsPerUnit, err := parseFloatOption(key, value)
+		if err != nil {
+			return err
+		}
+		u.PixelsPerUnit = pixelsPerUnit
+	default:
+		return unknownOption(u.ext, key)
+	}
+	return nil
+}
+
+func (u *UnityExporter) Export(atlasInfo *model.AtlasInfo) ([]byte, error) {
+	if err := checkNoTransforms(atlasInfo); err != nil {
+		return nil, err
+	}
+	if len(atlasInfo.Atlases) == 0 {
+		return nil, fmt.Errorf("no Atlas found")
+	}
+	atlas := atlasInfo.Atlases[0]
+	pixelsPerUnit := u.PixelsPerUnit
+	if pixelsPerUnit <= 0 {
+		pixelsPerUnit = 100
+	}
+	sprites := make([]unitySprite, len(atlas.Sprites))
+	for i, sprite := range atlas.Sprites {
+		if sprite.Rotated {
+			return nil, fmt.Errorf("sprite %s is rotated, unity does not support rotated sprites", sprite.FileName)
+		}
+		// position of the packed region inside the source image
+		trim := model.NewRectByPosAndSize(0, 0, sprite.Frame.W, sprite.Frame.H)
+		if sprite.Trimmed {
+			trim.Point = sprite.TrimmedRect.Point
+		}
+		srcSize := sprite.SrcRect
+		if srcSize.W == 0 || srcSize.H == 0 {
+			srcSize = sprite.Frame.Size
+		}
+		// unity pivots are normalized to the sliced rect with the origin at the bottom-left,
+		// model pivots are normalized to the source size with the origin at the top-left
+		pivot := model.Pivot{X: 0.5, Y: 0.5}
+		if sprite.Pivot != nil {
+			pivot = *sprite.Pivot
+		}
+		pivotX := (pivot.X*float64(srcSize.W) - float64(trim.X)) / float64(trim.W)
+		pivotY := (float64(trim.Y+trim.H) - pivot.Y*float64(srcSize.H)) / float64(trim.H)
+		alignment := 9 // custom
+		if pivotX == 0.5 && pivotY == 0.5 {
+			alignment = 0 // center
+		}
+		// unity borders are relative to the sliced rect
+		border := model.Border{
+			L: max(sprite.Border.L-trim.X, 0),
+			T: max(sprite.Border.T-trim.Y, 0),
+			R: max(sprite.Border.R-(srcSize.W-trim.X-trim.W), 0),
+			B: max(sprite.Border.B-(srcSize.H-trim.Y-trim.H), 0),
+		}
+		name := strings.TrimSuffix(sprite.FileName, filepath.Ext(sprite.FileName))
+		id := md5.Sum([]byte(atlas.Name + "/" + name))
+		sprites[i] = unitySprite{
+			Name:       strconv.Quote(name),
+			X:          sprite.Frame.X,
+			Y:          atlas.Size.H - sprite.Frame.Y - sprite.Frame.H,
+			W:          sprite.Frame.W,
+			H:          sprite.Frame.H,
+			Alignment:  alignment,
+			PivotX:     formatFloat(pivotX),
+			PivotY:     formatFloat(pivotY),
+			Border:     border,
+			SpriteID:   hex.EncodeToString(id[:]),
+			InternalID: int64(binary.BigEndian.Uint64(id[:8]) >> 1),
+		}
+	}
+	guid := md5.Sum([]byte(atlas.Name))
+	maxSize := model.Size{W: max(atlas.Size.W, atlas.Size.H, 32), H: 1}.PowerOfTwo()
+	data := unityTemplateData{
+		Guid:           hex.EncodeToString(guid[:]),
+		MaxTextureSize: maxSize.W,
+		PixelsPerUnit:  formatFloat(pixelsPerUnit),
+		Sprites:        sprites,
+	}
+
+	tmpl, err := template.New(u.Ext()).Parse(unityTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	return buf.Bytes(), err
+}
+
+func (u *UnityExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	return nil, errors.New("unity meta format does not support import")
+}
+
+const unityTemplate = `fileFormatVersion: 2
+guid: {{.Guid}}
+TextureImporter:
+  internalIDToNameTable: []
+  externalObjects: {}
+  serializedVersion: 12
+  mipmaps:
+    enableMipMap: 0
+  textureSettings:
+    serializedVersion: 2
+    filterMode: 1
+    wrapU: 1
+    wrapV: 1
+  maxTextureSize: {{.MaxTextureSize}}
+  nPOTScale: 0
+  alphaUsage: 1
+  alphaIsTransparency: 1
+  textureType: 8
+  textureShape: 1
+  spriteMode: 2
+  spriteExtrude: 1
+  spriteMeshType: 1
+  alignment: 0
+  spritePivot: {x: 0.5, y: 0.5}
+  spritePixelsToUnits: {{.PixelsPerUnit}}
+  spriteBorder: {x: 0, y: 0, z: 0, w: 0}
+  spriteSheet:
+    serializedVersion: 2
+    sprites:{{if not .Sprites}} []{{end}}
+    {{- range .Sprites}}
+    - serializedVersion: 2
+      name: {{.Name}}
+      rect:
+        serializedVersion: 2
+        x: {{.X}}
+        y: {{.Y}}
+        width: {{.W}}
+        height: {{.H}}
+      alignment: {{.Alignment}}
+      pivot: {x: {{.PivotX}}, y: {{.PivotY}}}
+      border: {x: {{.Border.L}}, y: {{.Border.B}}, z: {{.Border.R}}, w: {{.Border.T}}}
+      outline: []
+      physicsShape: []
+      tessellationDetail: 0
+      bones: []
+      spriteID: {{.SpriteID}}
+      internalID: {{.InternalID}}
+      vertices: []
+      indices:
+      edges: []
+      weights: []
+    {{- end}}
+    outline: []
+    physicsShape: []
+    bones: []
+    spriteID:
+    internalID: 0
+    vertices: []
+    indices:
+    edges: []
+    weights: []
+    secondaryTextures: []
+    nameFileIdTable:{{if not .Sprites}} {}{{end}}
+    {{- range .Sprites}}
+      {{.Name}}: {{.InternalID}}
+    {{- end}}
+  spritePackingTag:
+  pSDRemoveMatte: 0
+  userData:
+  assetBundleName:
+  assetBundleVariant:
+`
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"github.com/91xusir/spritepacker/model"
+	"gopkg.in/yaml.v3"
+)
+
+// YamlExporter exports model.AtlasInfo as yaml, with the same schema as JsonExporter.
+type YamlExporter struct {
+	ext string
+}
+
+func (y *YamlExporter) Ext() string {
+	return y.ext
+}
+func (y *YamlExporter) SetExt(ext string) {
+	y.ext = ext
+}
+
+func (y *YamlExporter) Polygons() bool {
+	return true
+}
+
+func (y *YamlExporter) Export(atlas *model.AtlasInfo) ([]byte, error) {
+	return yaml.Marshal(atlas)
+}
+
+// Detect recognises yaml with an "atlases" list.
+func (y *YamlExporter) Detect(data []byte) bool {
+	var raw struct {
+		Atlases []yaml.Node `yaml:"atlases"`
+	}
+	return yaml.Unmarshal(data, &raw) == nil && raw.Atlases != nil
+}
+
+func (y *YamlExporter) Import(data []byte) (*model.AtlasInfo, error) {
+	var atlas model.AtlasInfo
+	err := yaml.Unmarshal(data, &atlas)
+	return &atlas, err
+}
@@ -2,6 +2,9 @@ module github.com/91xusir/spritepacker
 
 go 1.24.2
 
-require golang.org/x/image v0.26.0
-
-require github.com/HugoSmits86/nativewebp v1.1.4 // indirect
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/HugoSmits86/nativewebp v1.1.4
+	golang.org/x/image v0.26.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -7,11 +7,13 @@ import (
 	"flag"
 	"fmt"
 	"github.com/91xusir/spritepacker/export"
+	"github.com/91xusir/spritepacker/model"
 	"github.com/91xusir/spritepacker/pack"
 	"github.com/91xusir/spritepacker/utils"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
 )
 
@@ -23,8 +25,41 @@ var (
 	name           string
 	infoFormat     string
 	imgFormat      string
+	tmplPath       string
+	tmplExt        string
+	tmplDir        string
+	exportOpts     optionFlags
+	slicePath      string
+	cellSize       string
+	cellCount      string
+	gridMargin     int
+	gridSpacing    int
+	skipEmpty      bool
+	detectPath     string
+	mergeDistance  int
+	minSize        int
+	tolerance      int
+	unpackFilter   string
+	unpackRegex    string
+	unpackAtlases  string
+	unpackRename   string
+	unpackTrimmed  bool
+	unpackAnim     string
+	frameDelay     int
 )
 
+// optionFlags collects the repeatable -opt flag
+type optionFlags []string
+
+func (o *optionFlags) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *optionFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
 // flagArgs function to parse the command line arguments and populate the options
 func flagArgs(opts *pack.Options) error {
 	// ---- atlas layout options ----
@@ -37,10 +72,17 @@ func flagArgs(opts *pack.Options) error {
 	// ---- sprite processing options ----
 	sort := flag.Bool("sort", true, "Sort sprites by Area before packing (default true)")
 	trim := flag.Bool("trim", false, "Trim transparent edges from sprites (default false)")
-	tolerance := flag.Int("tol", 0, "Tolerance level for trimming (0-255) (default 0)")
+	trimMode := flag.Int("trimmode", int(pack.TrimModeTrim), "Trim mode with -trim: 0=Trim (keep the source size), 1=CropKeepPos, 2=CropFlush (Default: Trim)")
+	trimMargin := flag.Int("trimmargin", 0, "Transparent pixels kept around the sprites when trimming (default 0)")
+	trimEdges := flag.String("trimedges", "ltrb", "Edges trimmed, initials of left, top, right and bottom, e.g. 'ltr' to keep the bottom (default 'ltrb')")
+	flag.IntVar(&tolerance, "tol", 0, "Tolerance level for trimming and sprite detection (0-255) (default 0)")
+	polygon := flag.Int("poly", 0, "Compute polygon outlines of at most this many vertices, exported as vertices and triangles (default 0, off)")
 	sameDetect := flag.Bool("same", false, "Enable identical image detection (default false)")
+	sameTransform := flag.Bool("sameflip", false, "Also detect flipped and rotated identical images with -same (default false)")
+	animations := flag.Bool("anims", false, "Detect animations from the sprite names, e.g. run_01..run_08 (default false)")
+	animPattern := flag.String("animre", "", "Regular expression of the animation frame names, its first group is the animation name")
 	// ---- algorithm settings ----
-	algorithm := flag.Int("algo", int(pack.AlgoSkyline), "Packing algorithm: 0=Basic, 1=Skyline, 2=MaxRects (Default: Skyline)")
+	algorithm := flag.Int("algo", int(pack.AlgoSkyline), "Packing algorithm: 0=Basic, 1=Skyline, 2=MaxRects, 3=Polygon with json, yaml, toml or template formats only (Default: Skyline)")
 	heuristic := flag.Int("heur", int(pack.BestShortSideFit), "Heuristic for MaxRects (if used) 0=BestShortSideFit, 1=BestLongSideFit, 2=BestAreaFit, 3=BottomLeftRule, 4=ContactPointRule (Default: BestShortSideFit)")
 	// ---- general settings ----
 	flag.StringVar(&name, "name", "atlas", "Atlas name (default 'atlas')")
@@ -48,8 +90,31 @@ func flagArgs(opts *pack.Options) error {
 	flag.StringVar(&outputPath, "o", "", "Output directory to save atlases or unpacked sprites")
 	flag.StringVar(&unpackJsonPath, "u", "", "Unpack from JSON file")
 	flag.StringVar(&atlasImgPath, "img", "", "Atlas image path for unpacking")
-	flag.StringVar(&infoFormat, "f1", "json", "Atlas info format  (default 'json')")
+	flag.StringVar(&unpackFilter, "filter", "", "Unpack the sprites whose name matches the glob, e.g. 'run_*'")
+	flag.StringVar(&unpackRegex, "regex", "", "Unpack the sprites whose name matches the regular expression")
+	flag.StringVar(&unpackAtlases, "atlas", "", "Unpack the atlases of the comma-separated indexes, e.g. '0,2'")
+	flag.StringVar(&unpackRename, "rename", "", "Output name template of the unpacked sprites, e.g. '{{.Atlas}}/{{.Name}}'")
+	flag.BoolVar(&unpackTrimmed, "trimmed", false, "Save trimmed sprites without restoring the source size (default false)")
+	flag.StringVar(&unpackAnim, "anim", "", "Save the animations as 'gif' or 'apng' instead of the sprites")
+	flag.IntVar(&frameDelay, "delay", 0, "Delay of the animation frames in milliseconds (default the frame durations or 100)")
+	flag.StringVar(&infoFormat, "f1", "json", "Atlas info formats, comma-separated: json, yaml, toml, tpsheet, plist, xml, css, html, meta, go, h, lua, defold.atlas (default 'json')")
 	flag.StringVar(&imgFormat, "f2", "png", "Atlas image format (default 'png')")
+	// ---- template settings ----
+	flag.StringVar(&tmplPath, "tmpl", "", "Go text/template file for the atlas info, e.g. 'phaser.js.tmpl' is used as format 'js'")
+	flag.StringVar(&tmplExt, "tmplext", "", "Format to register the -tmpl template under (default from the template file name)")
+	flag.StringVar(&tmplDir, "tmpldir", "", "Directory of '<name>.<format>.tmpl' templates usable with -f1")
+	flag.Var(&exportOpts, "opt", "Exporter option '[format:]key=value', repeatable, e.g. 'compact=true' or 'go:package=assets' (format defaults to -f1)")
+	// ---- grid slicing settings ----
+	flag.StringVar(&slicePath, "slice", "", "Slice a sprite sheet image without metadata into a grid")
+	flag.StringVar(&cellSize, "cell", "", "Cell size of the grid 'WxH', e.g. '32x32'")
+	flag.StringVar(&cellCount, "cells", "", "Columns and rows of the grid 'CxR', e.g. '8x4', instead of -cell")
+	flag.IntVar(&gridMargin, "margin", 0, "Border around the grid in pixels (default 0)")
+	flag.IntVar(&gridSpacing, "spacing", 0, "Space between the cells in pixels (default 0)")
+	flag.BoolVar(&skipEmpty, "skipempty", false, "Skip fully transparent cells (default false)")
+	// ---- sprite detection settings ----
+	flag.StringVar(&detectPath, "detect", "", "Detect the sprites of an atlas image without metadata")
+	flag.IntVar(&mergeDistance, "merge", 0, "Merge sprite parts at most this many pixels apart (default 0)")
+	flag.IntVar(&minSize, "minsize", 0, "Drop detected sprites smaller than this size in both dimensions (default 0)")
 	//version
 	vFlag := flag.Bool("v", false, "Show version")
 
@@ -71,22 +136,33 @@ func flagArgs(opts *pack.Options) error {
 		}
 		os.Exit(0)
 	}
+	edges, err := pack.ParseTrimEdges(*trimEdges)
+	if err != nil {
+		return err
+	}
 	// apply parsed flags to options
-	opts.MaxSize(*maxW, *maxH).
+	_, err = opts.MaxSize(*maxW, *maxH).
 		AutoSize(*autoSize).
 		Padding(*padding).
 		AllowRotate(*allowRotate).
 		PowerOfTwo(*powerOfTwo).
 		Sort(*sort).
 		Trim(*trim).
-		Tolerance(*tolerance).
+		TrimMode(pack.TrimMode(*trimMode)).
+		TrimMargin(*trimMargin).
+		TrimEdges(edges).
+		Polygon(*polygon).
+		Tolerance(tolerance).
 		SameDetect(*sameDetect).
+		SameTransform(*sameTransform).
+		Animations(*animations).
+		AnimationPattern(*animPattern).
 		ImgExt(imgFormat).
 		Name(name).
 		Algorithm(pack.Algorithm(*algorithm)).
-		Heuristic(pack.Heuristic(*heuristic))
-
-	return nil
+		Heuristic(pack.Heuristic(*heuristic)).
+		Validate()
+	return err
 }
 
 func main() {
@@ -99,8 +175,27 @@ func main() {
 	opts := pack.NewOptions()
 	check(flagArgs(opts))
 
+	exporter := export.NewExportManager().Init()
+	check(loadTemplates(exporter))
+	check(applyOptions(exporter))
+	for _, format := range infoFormats() {
+		if _, ok := exporter.Get(format); !ok {
+			check(fmt.Errorf("unsupported info format %s", format))
+		}
+	}
+
 	if unpackJsonPath != "" {
-		check(pack.UnpackSprites(unpackJsonPath, pack.WithImgInput(atlasImgPath), pack.WithOutput(outputPath)))
+		check(pack.UnpackSprites(unpackJsonPath, unpackOpts()...))
+		os.Exit(0)
+	}
+
+	if slicePath != "" {
+		check(sliceGrid(exporter))
+		os.Exit(0)
+	}
+
+	if detectPath != "" {
+		check(detectSprites(exporter))
 		os.Exit(0)
 	}
 
@@ -112,7 +207,7 @@ func main() {
 		check(err)
 		// if input path is a file, unpack it
 		if !f.IsDir() {
-			check(pack.UnpackSprites(inputPath, pack.WithImgInput(atlasImgPath), pack.WithOutput(outputPath)))
+			check(pack.UnpackSprites(inputPath, unpackOpts()...))
 			os.Exit(0)
 		}
 		// use default options if output path is not specified
@@ -124,6 +219,14 @@ func main() {
 	if inputPath == "" {
 		panic("input path is empty")
 	}
+	// the frames nested by their polygons overlap, the formats without polygons would bleed the neighbours
+	if opts.PackingAlgorithm() == pack.AlgoPolygon {
+		for _, format := range infoFormats() {
+			if !exporter.Polygons(format) {
+				check(fmt.Errorf("info format %s can not carry the sprite polygons of -algo 3", format))
+			}
+		}
+	}
 	fmt.Printf("input path: %s\n", inputPath)
 	fmt.Printf("output path: %s\n", outputPath)
 	fmt.Printf("info format: %s\n", infoFormat)
@@ -136,8 +239,172 @@ func main() {
 		filePath := filepath.Join(outputPath, spriteAtlasInfo.Atlases[i].Name)
 		check(utils.SaveImgByExt(filePath, atlasImages[i], utils.WithCLV(utils.DefaultCompression)))
 	}
-	exporter := export.NewExportManager().Init()
-	_ = exporter.Export(filepath.Join(outputPath, name+dotFormat(infoFormat)), spriteAtlasInfo)
+	check(exporter.ExportAll(filepath.Join(outputPath, name), infoFormats(), spriteAtlasInfo))
+}
+
+// unpackOpts returns the unpack options of the flags,
+// the sprites keep the format of their name unless -f2 is set.
+func unpackOpts() []pack.UnpackOpts {
+	opts := []pack.UnpackOpts{
+		pack.WithImgInput(atlasImgPath),
+		pack.WithOutput(outputPath),
+		pack.WithFilter(unpackFilter),
+		pack.WithRegexFilter(unpackRegex),
+		pack.WithNameTemplate(unpackRename),
+		pack.WithTrimmedOnly(unpackTrimmed),
+		pack.WithAnimation(unpackAnim),
+		pack.WithFrameDelay(frameDelay),
+	}
+	if unpackAtlases != "" {
+		var indexes []int
+		for _, s := range strings.Split(unpackAtlases, ",") {
+			index, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				check(fmt.Errorf("invalid -atlas %q, expected comma-separated indexes", unpackAtlases))
+			}
+			indexes = append(indexes, index)
+		}
+		opts = append(opts, pack.WithAtlases(indexes...))
+	}
+	if isFlagSet("f2") {
+		opts = append(opts, pack.WithImgFormat(imgFormat))
+	}
+	return opts
+}
+
+// sliceGrid slices the -slice image into a grid, saving the sprites and the atlas info in the -f1 formats.
+func sliceGrid(exporter *export.ExporterManager) error {
+	gridOpts := []pack.GridOpts{pack.WithMargin(gridMargin), pack.WithSpacing(gridSpacing), pack.WithSkipEmpty(skipEmpty)}
+	switch {
+	case cellSize != "":
+		w, h, err := parseSize("cell", cellSize)
+		if err != nil {
+			return err
+		}
+		gridOpts = append(gridOpts, pack.WithCellSize(w, h))
+	case cellCount != "":
+		cols, rows, err := parseSize("cells", cellCount)
+		if err != nil {
+			return err
+		}
+		gridOpts = append(gridOpts, pack.WithCells(cols, rows))
+	default:
+		return fmt.Errorf("-cell or -cells is required to slice %s", slicePath)
+	}
+	atlasInfo, err := pack.SliceGrid(slicePath, gridOpts...)
+	if err != nil {
+		return err
+	}
+	return saveSheet(exporter, slicePath, atlasInfo)
+}
+
+// detectSprites detects the sprites of the -detect image, saving the sprites and the atlas info in the -f1 formats.
+func detectSprites(exporter *export.ExporterManager) error {
+	if tolerance < 0 || tolerance > 255 {
+		return fmt.Errorf("tolerance must be in the range 0-255")
+	}
+	atlasInfo, err := pack.DetectSprites(detectPath,
+		pack.WithAlphaTolerance(uint8(tolerance)),
+		pack.WithMergeDistance(mergeDistance),
+		pack.WithMinSize(minSize))
+	if err != nil {
+		return err
+	}
+	return saveSheet(exporter, detectPath, atlasInfo)
+}
+
+// saveSheet unpacks the sprites of a sheet without metadata and exports its atlas info,
+// into the -o directory or the directory of the image.
+func saveSheet(exporter *export.ExporterManager, imgPath string, atlasInfo *model.AtlasInfo) error {
+	output := outputPath
+	if output == "" {
+		output = filepath.Dir(imgPath)
+	}
+	if err := pack.UnpackAtlas(atlasInfo, pack.WithImgInput(filepath.Dir(imgPath)), pack.WithOutput(output)); err != nil {
+		return err
+	}
+	baseName := strings.TrimSuffix(filepath.Base(imgPath), filepath.Ext(imgPath))
+	return exporter.ExportAll(filepath.Join(output, baseName), infoFormats(), atlasInfo)
+}
+
+// parseSize parses a 'WxH' flag value
+func parseSize(flagName, value string) (int, int, error) {
+	var w, h int
+	if _, err := fmt.Sscanf(strings.ToLower(value), "%dx%d", &w, &h); err != nil {
+		return 0, 0, fmt.Errorf("invalid -%s %q, expected WxH", flagName, value)
+	}
+	return w, h, nil
+}
+
+// loadTemplates registers the templates of -tmpldir and -tmpl,
+// -tmpl is used as the info format unless -f1 is set.
+func loadTemplates(exporter *export.ExporterManager) error {
+	if tmplDir != "" {
+		if _, err := exporter.LoadTemplateDir(tmplDir); err != nil {
+			return err
+		}
+	}
+	if tmplPath != "" {
+		ext, err := exporter.LoadTemplate(tmplPath, tmplExt)
+		if err != nil {
+			return err
+		}
+		if !isFlagSet("f1") {
+			infoFormat = ext
+		}
+	}
+	return nil
+}
+
+// applyOptions sets the -opt options on the exporters,
+// options without format apply to every -f1 format accepting them.
+func applyOptions(exporter *export.ExporterManager) error {
+	for _, opt := range exportOpts {
+		formats := infoFormats()
+		if i := strings.Index(opt, ":"); i >= 0 && i < strings.Index(opt, "=") {
+			formats, opt = []string{dotFormat(opt[:i])}, opt[i+1:]
+		}
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok {
+			return fmt.Errorf("invalid option %q, expected [format:]key=value", opt)
+		}
+		var firstErr error
+		applied := false
+		for _, format := range formats {
+			if err := exporter.SetOption(format, key, value); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			applied = true
+		}
+		if !applied {
+			return firstErr
+		}
+	}
+	return nil
+}
+
+// infoFormats returns the comma-separated -f1 formats as extensions
+func infoFormats() []string {
+	var formats []string
+	for _, format := range strings.Split(infoFormat, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formats = append(formats, dotFormat(format))
+		}
+	}
+	return formats
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
 func check(err error) {
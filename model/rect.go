@@ -9,8 +9,8 @@ import (
 // Size represents a size with width and height.
 // The width and height must be positive.
 type Size struct {
-	W int `json:"w"`
-	H int `json:"h"`
+	W int `json:"w" yaml:"w" toml:"w"`
+	H int `json:"h" yaml:"h" toml:"h"`
 }
 
 func (s Size) Clone() Size {
@@ -49,8 +49,8 @@ func nextPowerOfTwo(n int) int {
 }
 
 type Point struct {
-	X int `json:"x"`
-	Y int `json:"y"`
+	X int `json:"x" yaml:"x" toml:"x"`
+	Y int `json:"y" yaml:"y" toml:"y"`
 }
 
 func NewPoint(x, y int) Point {
@@ -63,10 +63,10 @@ func NewPoint(x, y int) Point {
 
 // Rect represents an immutable rectangle using value semantics.
 type Rect struct {
-	Point
-	Size
-	Id        int  `json:"-"`
-	IsRotated bool `json:"rotated,omitempty"`
+	Point     `yaml:",inline"`
+	Size      `yaml:",inline"`
+	Id        int  `json:"-" yaml:"-" toml:"-"`
+	IsRotated bool `json:"rotated,omitempty" yaml:"rotated,omitempty" toml:"rotated,omitempty"`
 }
 
 // NewRect creates a new Rect value.
@@ -1,33 +1,71 @@
 package model
 
 type AtlasInfo struct {
-	Meta    Meta    `json:"meta"`
-	Atlases []Atlas `json:"atlases"`
+	Meta       Meta        `json:"meta" yaml:"meta" toml:"meta"`
+	Atlases    []Atlas     `json:"atlases" yaml:"atlases" toml:"atlases"`
+	Animations []Animation `json:"animations,omitempty" yaml:"animations,omitempty" toml:"animations,omitempty"`
 }
 
 type Meta struct {
-	Repo      string `json:"repo"`
-	Format    string `json:"format"`
-	Version   string `json:"version"`
-	Timestamp string `json:"timestamp"`
+	Repo      string `json:"repo" yaml:"repo" toml:"repo"`
+	Format    string `json:"format" yaml:"format" toml:"format"`
+	Version   string `json:"version" yaml:"version" toml:"version"`
+	Timestamp string `json:"timestamp" yaml:"timestamp" toml:"timestamp"`
 }
 
 type Atlas struct {
-	Name    string   `json:"name"`
-	Size    Size     `json:"size"`
-	Sprites []Sprite `json:"sprites"`
+	Name    string   `json:"name" yaml:"name" toml:"name"`
+	Size    Size     `json:"size" yaml:"size" toml:"size"`
+	Sprites []Sprite `json:"sprites" yaml:"sprites" toml:"sprites"`
 }
 
 type Sprite struct {
-	FileName    string `json:"filename"`
-	Frame       Rect   `json:"frame"`
-	SrcRect     Size   `json:"srcRect"`
-	TrimmedRect Rect   `json:"trimmedRect,omitzero"`
-	Rotated     bool   `json:"rotated"`
-	Trimmed     bool   `json:"trimmed"`
+	FileName    string `json:"filename" yaml:"filename" toml:"filename"`
+	Frame       Rect   `json:"frame" yaml:"frame" toml:"frame"`
+	SrcRect     Size   `json:"srcRect" yaml:"srcRect" toml:"srcRect"`
+	TrimmedRect Rect   `json:"trimmedRect,omitzero" yaml:"trimmedRect,omitempty" toml:"trimmedRect,omitempty"`
+	Rotated     bool   `json:"rotated" yaml:"rotated" toml:"rotated"`
+	Trimmed     bool   `json:"trimmed" yaml:"trimmed" toml:"trimmed"`
+	Pivot       *Pivot `json:"pivot,omitempty" yaml:"pivot,omitempty" toml:"pivot,omitempty"`
+	Border      Border `json:"border,omitzero" yaml:"border,omitempty" toml:"border,omitempty"`
+	Duration    int    `json:"duration,omitempty" yaml:"duration,omitempty" toml:"duration,omitempty"` // frame duration in milliseconds
+	Transform   string `json:"transform,omitempty" yaml:"transform,omitempty" toml:"transform,omitempty"`
+	AliasOf     string `json:"aliasOf,omitempty" yaml:"aliasOf,omitempty" toml:"aliasOf,omitempty"` // file name of the sprite whose pixels are reused
+	// Vertices is the polygon outline of the opaque pixels in the source sprite, Triangles indexes its vertices.
+	Vertices  []Point  `json:"vertices,omitempty" yaml:"vertices,omitempty" toml:"vertices,omitempty"`
+	Triangles [][3]int `json:"triangles,omitempty" yaml:"triangles,omitempty" toml:"triangles,omitempty"`
+}
+
+// Pivot is the pivot point of the sprite normalized to the source size,
+// (0,0) is the top-left corner and (1,1) the bottom-right corner.
+// A nil pivot means the center of the sprite.
+type Pivot struct {
+	X float64 `json:"x" yaml:"x" toml:"x"`
+	Y float64 `json:"y" yaml:"y" toml:"y"`
+}
+
+// Border is the 9-slice border of the sprite in pixels of the source image.
+type Border struct {
+	L int `json:"l" yaml:"l" toml:"l"`
+	T int `json:"t" yaml:"t" toml:"t"`
+	R int `json:"r" yaml:"r" toml:"r"`
+	B int `json:"b" yaml:"b" toml:"b"`
 }
 
 func (s Sprite) Clone() Sprite {
+	var pivot *Pivot
+	if s.Pivot != nil {
+		p := *s.Pivot
+		pivot = &p
+	}
+	var vertices []Point
+	if s.Vertices != nil {
+		vertices = append([]Point{}, s.Vertices...)
+	}
+	var triangles [][3]int
+	if s.Triangles != nil {
+		triangles = append([][3]int{}, s.Triangles...)
+	}
 	return Sprite{
 		FileName:    s.FileName,
 		Frame:       s.Frame.Clone(),
@@ -35,5 +73,42 @@ func (s Sprite) Clone() Sprite {
 		TrimmedRect: s.TrimmedRect.Clone(),
 		Rotated:     s.Rotated,
 		Trimmed:     s.Trimmed,
+		Pivot:       pivot,
+		Border:      s.Border,
+		Duration:    s.Duration,
+		Transform:   s.Transform,
+		AliasOf:     s.AliasOf,
+		Vertices:    vertices,
+		Triangles:   triangles,
 	}
 }
+
+// Sprite transforms, the pixels of a sprite with a Transform are the pixels of its Frame,
+// unrotated, then transformed. SrcRect and TrimmedRect are those of the transformed sprite.
+const (
+	FlipX      = "flipX"      // mirrored from left to right
+	FlipY      = "flipY"      // mirrored from top to bottom
+	Rotate90   = "rotate90"   // rotated 90 degrees clockwise
+	Rotate180  = "rotate180"  // rotated 180 degrees
+	Rotate270  = "rotate270"  // rotated 270 degrees clockwise
+	Transpose  = "transpose"  // mirrored along the top-left to bottom-right diagonal
+	Transverse = "transverse" // mirrored along the bottom-left to top-right diagonal
+)
+
+// Animation directions
+const (
+	Forward         = "forward"
+	Reverse         = "reverse"
+	PingPong        = "pingpong"
+	PingPongReverse = "pingpong_reverse"
+)
+
+// Animation is a sequence of sprites referenced by name, played in Direction, Forward by default.
+// Fps and Loop are optional, 0 fps means the sprite durations and a nil loop means looping.
+type Animation struct {
+	Name      string   `json:"name" yaml:"name" toml:"name"`
+	Frames    []string `json:"frames" yaml:"frames" toml:"frames"`
+	Direction string   `json:"direction,omitempty" yaml:"direction,omitempty" toml:"direction,omitempty"`
+	Fps       float64  `json:"fps,omitempty" yaml:"fps,omitempty" toml:"fps,omitempty"`
+	Loop      *bool    `json:"loop,omitempty" yaml:"loop,omitempty" toml:"loop,omitempty"`
+}
@@ -9,6 +9,10 @@ const (
 	AlgoBasic Algorithm = iota
 	AlgoSkyline
 	AlgoMaxRects
+	// AlgoPolygon nests the sprites into the empty corners of each other by their polygon outline,
+	// the bounding rects of the sprites may overlap so they must be drawn with their vertices.
+	// The polygons are computed with 16 vertices when they are not enabled.
+	AlgoPolygon
 	MaxAlgoIndex
 )
 
@@ -31,6 +35,12 @@ type algo interface {
 	reset(w, h int)                                             // ResetWH resets the width and height of the bin.
 }
 
+// usedAreaAlgo is implemented by the algos whose packed rects may overlap,
+// it returns the area really used by a rect.
+type usedAreaAlgo interface {
+	usedArea(rect model.Rect) int
+}
+
 // algoBasic basicAlgorithms
 type algoBasic struct {
 	w, h        int  // The width and height of the bin
@@ -0,0 +1,371 @@
+package pack
+
+import (
+	"github.com/91xusir/spritepacker/model"
+	"image"
+	"math/bits"
+)
+
+// polygonAlgoVertices is the maximum vertices of the polygon outlines computed for AlgoPolygon
+// when the polygons are not enabled.
+const polygonAlgoVertices = 16
+
+// span is a run of occupied pixels in a row, from included to excluded.
+type span struct{ from, to int }
+
+// shape is the occupied pixels of a rect, padding included, as runs by row.
+type shape struct {
+	w, h   int
+	rows   [][]span
+	counts []int // occupied pixels by row
+	area   int
+}
+
+type shapeKey struct {
+	id      int
+	rotated bool
+}
+
+// algoPolygon is a packing algo that nests the sprites into the empty corners of each other with
+// bottom-left fill over an occupancy bitmap, the bounding rects of the packed sprites may overlap.
+// The sprites occupy their polygon outline and their opaque pixels, the whole rect when it has no mask.
+type algoPolygon struct {
+	algoBasic
+	padding int
+	masks   map[int]*alphaMask // occupied pixels by rect id, without padding and rotation
+	shapes  map[shapeKey]*shape
+	grid    [][]uint64 // occupied pixels of the bin by row
+	free    []int      // free pixels of the bin by row
+}
+
+func (algo *algoPolygon) init(opt *Options) {
+	algo.algoBasic.init(opt)
+	algo.padding = opt.padding
+	algo.shapes = make(map[shapeKey]*shape)
+	algo.reset(algo.w, algo.h)
+}
+
+func (algo *algoPolygon) reset(w, h int) {
+	algo.w, algo.h = w, h
+	words := (w + 63) / 64
+	algo.grid = make([][]uint64, h)
+	algo.free = make([]int, h)
+	for y := range algo.grid {
+		algo.grid[y] = make([]uint64, words)
+		algo.free[y] = w
+	}
+}
+
+func (algo *algoPolygon) packing(reqRects []model.Rect) ([]model.Rect, []model.Rect) {
+	packedRects := make([]model.Rect, 0, len(reqRects))
+	unpackedRects := make([]model.Rect, 0)
+	for _, reqRect := range reqRects {
+		candidates := []model.Rect{reqRect}
+		if algo.allowRotate {
+			candidates = append(candidates, reqRect.Rotated())
+		}
+		found := false
+		var best model.Rect
+		var bestShape *shape
+		for _, candidate := range candidates {
+			limitY := algo.h
+			if found {
+				limitY = best.Y
+			}
+			s := algo.shape(candidate)
+			x, y, ok := algo.find(s, limitY)
+			if ok && (!found || y < best.Y || y == best.Y && x < best.X) {
+				best, bestShape, found = candidate, s, true
+				best.X, best.Y = x, y
+			}
+		}
+		if !found {
+			unpackedRects = append(unpackedRects, reqRect)
+			continue
+		}
+		algo.occupy(bestShape, best.X, best.Y)
+		packedRects = append(packedRects, best)
+	}
+	return packedRects, unpackedRects
+}
+
+// usedArea returns the pixels occupied by the rect, smaller than its area when it has a mask.
+func (algo *algoPolygon) usedArea(rect model.Rect) int {
+	return algo.shape(rect).area
+}
+
+// find returns the top-most then left-most position of the shape, not below limitY.
+func (algo *algoPolygon) find(s *shape, limitY int) (int, int, bool) {
+	hint := 0 // the row of the last collision, likely to collide again
+	for y := 0; y+s.h <= algo.h && y <= limitY; y++ {
+		if !algo.fitsRows(s, y) {
+			continue
+		}
+		for x := 0; x+s.w <= algo.w; {
+			next := algo.collision(s, x, y, &hint)
+			if next < 0 {
+				return x, y, true
+			}
+			x = next
+		}
+	}
+	return 0, 0, false
+}
+
+// fitsRows reports whether the rows of the bin have enough free pixels for the shape at y.
+func (algo *algoPolygon) fitsRows(s *shape, y int) bool {
+	for r, count := range s.counts {
+		if algo.free[y+r] < count {
+			return false
+		}
+	}
+	return true
+}
+
+// collision returns -1 if the shape fits at the position, else the next x where it may fit in the row,
+// after the first occupied pixel found. The hint row is checked first and updated.
+func (algo *algoPolygon) collision(s *shape, x, y int, hint *int) int {
+	for i := range s.rows {
+		r := (*hint + i) % s.h
+		row := algo.grid[y+r]
+		for _, sp := range s.rows[r] {
+			if last := lastSet(row, x+sp.from, x+sp.to); last >= 0 {
+				*hint = r
+				// the span starts at the next free pixel
+				return nextClear(row, last+1, algo.w) - sp.from
+			}
+		}
+	}
+	return -1
+}
+
+func (algo *algoPolygon) occupy(s *shape, x, y int) {
+	for r, spans := range s.rows {
+		row := algo.grid[y+r]
+		for _, sp := range spans {
+			for i := x + sp.from; i < x+sp.to; i++ {
+				row[i>>6] |= 1 << (i & 63)
+			}
+			algo.free[y+r] -= sp.to - sp.from
+		}
+	}
+}
+
+// lastSet returns the last set bit of the row in [from, to), -1 if none.
+func lastSet(row []uint64, from, to int) int {
+	if from >= to {
+		return -1
+	}
+	first, last := from>>6, (to-1)>>6
+	for i := last; i >= first; i-- {
+		word := row[i]
+		if i == last && to&63 != 0 {
+			word &= 1<<(to&63) - 1
+		}
+		if i == first {
+			word &= ^uint64(0) << (from & 63)
+		}
+		if word != 0 {
+			return i<<6 + bits.Len64(word) - 1
+		}
+	}
+	return -1
+}
+
+// nextClear returns the first clear bit of the row from the index, w if none.
+func nextClear(row []uint64, from, w int) int {
+	for i := from >> 6; i < len(row); i++ {
+		word := ^row[i]
+		if i == from>>6 {
+			word &= ^uint64(0) << (from & 63)
+		}
+		if word != 0 {
+			return min(i<<6+bits.TrailingZeros64(word), w)
+		}
+	}
+	return w
+}
+
+// shape returns the occupied pixels of the rect, rotated clockwise if the rect is rotated
+// and extended to the right and the bottom by the padding.
+func (algo *algoPolygon) shape(rect model.Rect) *shape {
+	key := shapeKey{id: rect.Id, rotated: rect.IsRotated}
+	if s, ok := algo.shapes[key]; ok && s.w == rect.W && s.h == rect.H {
+		return s
+	}
+	w, h := rect.W-algo.padding, rect.H-algo.padding
+	mask := algo.masks[rect.Id]
+	if mask != nil && rect.IsRotated {
+		mask = rotateMask(mask)
+	}
+	if mask == nil || mask.w != w || mask.h != h {
+		// no mask, the whole rect is occupied
+		mask = &alphaMask{w: w, h: h, opaque: make([]bool, w*h)}
+		for i := range mask.opaque {
+			mask.opaque[i] = true
+		}
+	}
+	padded := &alphaMask{w: rect.W, h: rect.H, opaque: make([]bool, rect.W*rect.H)}
+	for y := 0; y < mask.h; y++ {
+		for x := 0; x < mask.w; x++ {
+			if !mask.at(x, y) {
+				continue
+			}
+			for dy := 0; dy <= algo.padding; dy++ {
+				for dx := 0; dx <= algo.padding; dx++ {
+					padded.opaque[(y+dy)*padded.w+x+dx] = true
+				}
+			}
+		}
+	}
+	s := &shape{w: rect.W, h: rect.H, rows: make([][]span, rect.H), counts: make([]int, rect.H)}
+	for y := 0; y < padded.h; y++ {
+		for x := 0; x < padded.w; x++ {
+			if !padded.at(x, y) {
+				continue
+			}
+			if n := len(s.rows[y]); n > 0 && s.rows[y][n-1].to == x {
+				s.rows[y][n-1].to++
+			} else {
+				s.rows[y] = append(s.rows[y], span{from: x, to: x + 1})
+			}
+			s.counts[y]++
+			s.area++
+		}
+	}
+	algo.shapes[key] = s
+	return s
+}
+
+// rotateMask rotates the mask 90 degrees clockwise, as the rotated sprites in the atlas.
+func rotateMask(mask *alphaMask) *alphaMask {
+	rotated := &alphaMask{w: mask.h, h: mask.w, opaque: make([]bool, len(mask.opaque))}
+	for y := 0; y < mask.h; y++ {
+		for x := 0; x < mask.w; x++ {
+			rotated.opaque[x*rotated.w+mask.h-1-y] = mask.at(x, y)
+		}
+	}
+	return rotated
+}
+
+// spriteMasks returns the pixels occupied by the trimmed sprites with a polygon by index for AlgoPolygon.
+func (p *Packer) spriteMasks(spritePaths []string, trimmedRectMap map[int]model.Rect, polygons map[int]*polygon) map[int]*alphaMask {
+	masks := make(map[int]*alphaMask)
+	for i, spritePath := range spritePaths {
+		img, err := p.loadSprite(spritePath)
+		if err != nil {
+			continue // Skip non-image files
+		}
+		poly, ok := polygons[i]
+		if !ok {
+			// no polygon, e.g. no opaque pixels, the whole rect is occupied
+			continue
+		}
+		content := image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy())
+		if trimmedRect, ok := trimmedRectMap[i]; ok {
+			content = trimmedRect.ToImageRect()
+		}
+		masks[i] = occupancyMask(img, content, poly.vertices, p.option.tolerance)
+	}
+	return masks
+}
+
+// occupancyMask returns the pixels of the content rect of the image covered by the polygon
+// or with an alpha above the tolerance, the content rect and the vertices are relative to the image bounds.
+func occupancyMask(img image.Image, content image.Rectangle, vertices []model.Point, tolerance uint8) *alphaMask {
+	mask := polygonMask(vertices, content)
+	b := img.Bounds()
+	for y := 0; y < mask.h; y++ {
+		for x := 0; x < mask.w; x++ {
+			if _, _, _, a := img.At(b.Min.X+content.Min.X+x, b.Min.Y+content.Min.Y+y).RGBA(); uint8(a>>8) > tolerance {
+				mask.opaque[y*mask.w+x] = true
+			}
+		}
+	}
+	return mask
+}
+
+// polygonMask returns the pixels of the rect overlapping the inside of the polygon,
+// the pixels whose center is inside or crossed by an edge.
+func polygonMask(vertices []model.Point, rect image.Rectangle) *alphaMask {
+	mask := &alphaMask{w: rect.Dx(), h: rect.Dy(), opaque: make([]bool, rect.Dx()*rect.Dy())}
+	if len(vertices) < 3 {
+		return mask
+	}
+	// doubled coordinates, the pixel centers are on integers
+	doubled := make([]model.Point, len(vertices))
+	for i, v := range vertices {
+		doubled[i] = model.Point{X: 2 * (v.X - rect.Min.X), Y: 2 * (v.Y - rect.Min.Y)}
+	}
+	for y := 0; y < mask.h; y++ {
+		for x := 0; x < mask.w; x++ {
+			if containsPoint(doubled, model.Point{X: 2*x + 1, Y: 2*y + 1}) {
+				mask.opaque[y*mask.w+x] = true
+			}
+		}
+	}
+	for i, a := range doubled {
+		b := doubled[(i+1)%len(doubled)]
+		for y := max(min(a.Y, b.Y)/2-1, 0); y < min(max(a.Y, b.Y)/2+1, mask.h); y++ {
+			for x := max(min(a.X, b.X)/2-1, 0); x < min(max(a.X, b.X)/2+1, mask.w); x++ {
+				if segmentCrossesSquare(a, b, 2*x, 2*y, 2) {
+					mask.opaque[y*mask.w+x] = true
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// segmentCrossesSquare reports whether the segment passes through the inside of the square,
+// clipping the segment to the square as Liang-Barsky.
+func segmentCrossesSquare(a, b model.Point, x0, y0, size int) bool {
+	dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+	t0, t1 := 0.0, 1.0
+	for _, pq := range [][2]float64{
+		{-dx, float64(a.X - x0)}, {dx, float64(x0 + size - a.X)},
+		{-dy, float64(a.Y - y0)}, {dy, float64(y0 + size - a.Y)},
+	} {
+		p, q := pq[0], pq[1]
+		if p == 0 {
+			// parallel, on the border or outside
+			if q <= 0 {
+				return false
+			}
+			continue
+		}
+		if t := q / p; p < 0 {
+			t0 = max(t0, t)
+		} else {
+			t1 = min(t1, t)
+		}
+	}
+	if t0 >= t1 {
+		return false
+	}
+	t := (t0 + t1) / 2
+	mx, my := float64(a.X)+t*dx, float64(a.Y)+t*dy
+	return float64(x0) < mx && mx < float64(x0+size) && float64(y0) < my && my < float64(y0+size)
+}
+
+// spriteAtlasMask returns the pixels occupied by the sprite in the atlas, in atlas coordinates.
+func spriteAtlasMask(img image.Image, sprite model.Sprite, tolerance uint8) *image.Alpha {
+	content := image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy())
+	if sprite.Trimmed {
+		content = sprite.TrimmedRect.ToImageRect()
+	}
+	mask := occupancyMask(img, content, sprite.Vertices, tolerance)
+	if sprite.Rotated {
+		mask = rotateMask(mask)
+	}
+	frame := sprite.Frame.ToImageRect()
+	alpha := image.NewAlpha(frame)
+	for y := 0; y < min(mask.h, frame.Dy()); y++ {
+		for x := 0; x < min(mask.w, frame.Dx()); x++ {
+			if mask.at(x, y) {
+				alpha.Pix[y*alpha.Stride+x] = 0xff
+			}
+		}
+	}
+	return alpha
+}
@@ -0,0 +1,208 @@
+package pack
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"github.com/91xusir/spritepacker/utils"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// animFrame is a sprite restored to its source size.
+type animFrame struct {
+	img      image.Image
+	duration int
+}
+
+// DefaultAnimationPattern matches the names of animation frames with a numeric suffix, e.g. run_01,
+// the first group is the name of the animation.
+const DefaultAnimationPattern = `^(.*?)[_\-. ]*(\d+)$`
+
+// AnimationConfigFile is the optional sidecar file in the input directory setting the fps, loop
+// and direction of the detected animations by name, e.g. {"run": {"fps": 12, "loop": false}}.
+const AnimationConfigFile = "animations.json"
+
+// animationConfig is an entry of the AnimationConfigFile
+type animationConfig struct {
+	Fps       float64 `json:"fps"`
+	Loop      *bool   `json:"loop"`
+	Direction string  `json:"direction"`
+}
+
+// DetectAnimations groups the sprites whose name without extension matches pattern by the first group of pattern,
+// into animations of at least two frames sorted by name, the frames are sorted in natural order.
+// DefaultAnimationPattern is used when pattern is empty.
+//
+// Example:
+//
+//	atlasInfo.Animations, err = DetectAnimations(atlasInfo, `^(.+)-frame(\d+)$`)
+func DetectAnimations(atlasInfo *model.AtlasInfo, pattern string) ([]model.Animation, error) {
+	re, err := compileAnimationPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, atlas := range atlasInfo.Atlases {
+		for _, sprite := range atlas.Sprites {
+			if !seen[sprite.FileName] {
+				seen[sprite.FileName] = true
+				names = append(names, sprite.FileName)
+			}
+		}
+	}
+	return groupFrames(names, re), nil
+}
+
+func compileAnimationPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		pattern = DefaultAnimationPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid animation pattern %q: %v", pattern, err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("animation pattern %q has no group for the animation name", pattern)
+	}
+	return re, nil
+}
+
+// groupFrames groups the names by the first group of re.
+func groupFrames(names []string, re *regexp.Regexp) []model.Animation {
+	frames := make(map[string][]string)
+	for _, name := range names {
+		match := re.FindStringSubmatch(strings.TrimSuffix(name, filepath.Ext(name)))
+		if match == nil || match[1] == "" {
+			continue
+		}
+		frames[match[1]] = append(frames[match[1]], name)
+	}
+	var animations []model.Animation
+	for name, names := range frames {
+		if len(names) > 1 {
+			utils.NaturalSort(names)
+			animations = append(animations, model.Animation{Name: name, Frames: names})
+		}
+	}
+	slices.SortFunc(animations, func(a, b model.Animation) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return animations
+}
+
+// applyAnimationConfig sets the fps, loop and direction of the animations from the config file, if it exists.
+func applyAnimationConfig(animations []model.Animation, configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var configs map[string]animationConfig
+	if err = json.Unmarshal(data, &configs); err != nil {
+		return fmt.Errorf("%s: %v", configPath, err)
+	}
+	for i := range animations {
+		config, ok := configs[animations[i].Name]
+		if !ok {
+			continue
+		}
+		switch config.Direction {
+		case "", model.Forward, model.Reverse, model.PingPong, model.PingPongReverse:
+		default:
+			return fmt.Errorf("%s: unknown direction %q of %s", configPath, config.Direction, animations[i].Name)
+		}
+		animations[i].Fps = config.Fps
+		animations[i].Loop = config.Loop
+		animations[i].Direction = config.Direction
+	}
+	return nil
+}
+
+// playOrder returns the frames in the order they are played once.
+func playOrder(animation model.Animation) []string {
+	frames := slices.Clone(animation.Frames)
+	switch animation.Direction {
+	case model.Reverse, model.PingPongReverse:
+		slices.Reverse(frames)
+	}
+	switch animation.Direction {
+	case model.PingPong, model.PingPongReverse:
+		for i := len(frames) - 2; i > 0; i-- {
+			frames = append(frames, frames[i])
+		}
+	}
+	return frames
+}
+
+// saveAnimations saves the animations whose frames were unpacked as <animation name><animExt>,
+// frames are aligned on the top-left corner of their source image.
+func saveAnimations(atlasInfo *model.AtlasInfo, frames map[string]animFrame, opts *unpackedOpts) error {
+	animations := atlasInfo.Animations
+	if len(animations) == 0 {
+		var err error
+		if animations, err = DetectAnimations(atlasInfo, ""); err != nil {
+			return err
+		}
+	}
+	for _, animation := range animations {
+		var images []image.Image
+		var delays []int
+		canvas := image.Rectangle{}
+		for _, name := range playOrder(animation) {
+			frame, ok := frames[name]
+			if !ok {
+				continue
+			}
+			canvas = canvas.Union(frame.img.Bounds())
+			images = append(images, frame.img)
+			delay := opts.frameDelay
+			if delay <= 0 && animation.Fps > 0 {
+				delay = int(1000/animation.Fps + 0.5)
+			}
+			if delay <= 0 {
+				delay = frame.duration
+			}
+			if delay <= 0 {
+				delay = 100
+			}
+			delays = append(delays, delay)
+		}
+		if len(images) == 0 {
+			continue
+		}
+		// frames of different source sizes are drawn on the same canvas
+		for i, img := range images {
+			if img.Bounds() != canvas {
+				frame := image.NewNRGBA(canvas)
+				draw.Draw(frame, img.Bounds(), img, img.Bounds().Min, draw.Src)
+				images[i] = frame
+			}
+		}
+		outputPath := filepath.Join(opts.outputPath, animation.Name+opts.animExt)
+		if err := saveAnimation(outputPath, images, delays, opts.animExt); err != nil {
+			return fmt.Errorf("failed to save animation %s: %v", outputPath, err)
+		}
+	}
+	return nil
+}
+
+func saveAnimation(outputPath string, images []image.Image, delays []int, ext string) error {
+	file, err := utils.SafeCreate(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if ext == ".gif" {
+		return utils.EncodeGIF(file, images, delays)
+	}
+	return utils.EncodeAPNG(file, images, delays)
+}
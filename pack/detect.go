@@ -0,0 +1,160 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"github.com/91xusir/spritepacker/utils"
+	"image"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type detectOpts struct {
+	tolerance     uint8 // pixels with an alpha above the tolerance are opaque, as for trimming
+	mergeDistance int   // sprites whose bounds are at most this distance apart are merged
+	minSize       int   // sprites smaller in both dimensions are dropped
+}
+type DetectOpts func(*detectOpts)
+
+// WithAlphaTolerance sets the alpha tolerance, pixels with an alpha above it belong to sprites.
+func WithAlphaTolerance(tolerance uint8) DetectOpts {
+	return func(opts *detectOpts) {
+		opts.tolerance = tolerance
+	}
+}
+
+// WithMergeDistance merges the parts whose bounds are at most distance pixels apart,
+// e.g. for particles or glyphs made of disjoint parts.
+func WithMergeDistance(distance int) DetectOpts {
+	return func(opts *detectOpts) {
+		opts.mergeDistance = distance
+	}
+}
+
+// WithMinSize drops the sprites smaller than size in both dimensions, e.g. stray pixels.
+func WithMinSize(size int) DetectOpts {
+	return func(opts *detectOpts) {
+		opts.minSize = size
+	}
+}
+
+// DetectSprites finds the sprites of an atlas image without metadata,
+// as the bounds of the connected opaque pixels, the returned atlas info can be exported or unpacked with UnpackAtlas.
+// Sprites are named <image name>_<index>.png, in reading order.
+//
+// Example:
+//
+//	atlasInfo, err := DetectSprites("./old_atlas.png", WithMergeDistance(2), WithMinSize(4))
+func DetectSprites(imgPath string, fn ...DetectOpts) (*model.AtlasInfo, error) {
+	img, err := utils.LoadImg(imgPath)
+	if err != nil {
+		return nil, err
+	}
+	opts := &detectOpts{}
+	for _, f := range fn {
+		f(opts)
+	}
+	if opts.mergeDistance < 0 {
+		return nil, errors.New("merge distance must not be negative")
+	}
+	bounds := img.Bounds()
+	rects := mergeRects(connectedBounds(img, opts.tolerance), opts.mergeDistance)
+
+	baseName := filepath.Base(imgPath)
+	name := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	atlas := model.Atlas{
+		Name: baseName,
+		Size: model.Size{W: bounds.Dx(), H: bounds.Dy()},
+	}
+	for _, r := range rects {
+		if r.Dx() < opts.minSize && r.Dy() < opts.minSize {
+			continue
+		}
+		r = r.Sub(bounds.Min)
+		atlas.Sprites = append(atlas.Sprites, model.Sprite{
+			FileName: fmt.Sprintf("%s_%d.png", name, len(atlas.Sprites)),
+			Frame:    model.NewRectByPosAndSize(r.Min.X, r.Min.Y, r.Dx(), r.Dy()),
+			SrcRect:  model.Size{W: r.Dx(), H: r.Dy()},
+		})
+	}
+	return &model.AtlasInfo{
+		Meta:    getMateData(),
+		Atlases: []model.Atlas{atlas},
+	}, nil
+}
+
+// connectedBounds returns the bounds of the 8-connected components of opaque pixels.
+func connectedBounds(img image.Image, tolerance uint8) []image.Rectangle {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	opaque := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			_, _, _, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			opaque[y*w+x] = uint8(a>>8) > tolerance
+		}
+	}
+
+	var rects []image.Rectangle
+	visited := make([]bool, w*h)
+	var stack []int
+	for start := range opaque {
+		if !opaque[start] || visited[start] {
+			continue
+		}
+		// flood fill the component
+		visited[start] = true
+		stack = append(stack[:0], start)
+		r := image.Rect(start%w, start/w, start%w+1, start/w+1)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%w, i/w
+			r = r.Union(image.Rect(x, y, x+1, y+1))
+			for ny := max(y-1, 0); ny <= min(y+1, h-1); ny++ {
+				for nx := max(x-1, 0); nx <= min(x+1, w-1); nx++ {
+					if j := ny*w + nx; opaque[j] && !visited[j] {
+						visited[j] = true
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+		rects = append(rects, r.Add(bounds.Min))
+	}
+	return rects
+}
+
+// mergeRects merges the rects at most distance pixels apart until none is left,
+// overlapping rects are always merged, touching rects only when distance > 0. The result is sorted in reading order.
+func mergeRects(rects []image.Rectangle, distance int) []image.Rectangle {
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(rects); i++ {
+			for j := i + 1; j < len(rects); j++ {
+				if rects[i].Overlaps(rects[j]) || (distance > 0 && rectDistance(rects[i], rects[j]) <= distance) {
+					rects[i] = rects[i].Union(rects[j])
+					rects = append(rects[:j], rects[j+1:]...)
+					merged = true
+					j = i
+				}
+			}
+		}
+	}
+	sort.Slice(rects, func(i, j int) bool {
+		if rects[i].Min.Y != rects[j].Min.Y {
+			return rects[i].Min.Y < rects[j].Min.Y
+		}
+		return rects[i].Min.X < rects[j].Min.X
+	})
+	return rects
+}
+
+// rectDistance returns the number of pixels between a and b along the farthest axis, 0 when they touch or overlap.
+func rectDistance(a, b image.Rectangle) int {
+	dx := max(a.Min.X-b.Max.X, b.Min.X-a.Max.X, 0)
+	dy := max(a.Min.Y-b.Max.Y, b.Min.Y-a.Max.Y, 0)
+	return max(dx, dy)
+}
@@ -0,0 +1,142 @@
+package pack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/91xusir/spritepacker/model"
+	"github.com/91xusir/spritepacker/utils"
+	"image"
+	"path/filepath"
+	"strings"
+)
+
+type gridOpts struct {
+	cellW, cellH int  // cell size, derived from cols and rows when 0
+	cols, rows   int  // number of cells, derived from the cell size when 0
+	margin       int  // transparent border around the grid
+	spacing      int  // space between the cells
+	skipEmpty    bool // skip fully transparent cells
+}
+type GridOpts func(*gridOpts)
+
+// WithCellSize sets the size of the cells.
+func WithCellSize(w, h int) GridOpts {
+	return func(opts *gridOpts) {
+		opts.cellW = w
+		opts.cellH = h
+	}
+}
+
+// WithCells sets the number of columns and rows, the cell size is derived from the image size.
+func WithCells(cols, rows int) GridOpts {
+	return func(opts *gridOpts) {
+		opts.cols = cols
+		opts.rows = rows
+	}
+}
+
+// WithMargin sets the border around the grid in pixels.
+func WithMargin(margin int) GridOpts {
+	return func(opts *gridOpts) {
+		opts.margin = margin
+	}
+}
+
+// WithSpacing sets the space between the cells in pixels.
+func WithSpacing(spacing int) GridOpts {
+	return func(opts *gridOpts) {
+		opts.spacing = spacing
+	}
+}
+
+// WithSkipEmpty skips the fully transparent cells.
+func WithSkipEmpty(enable bool) GridOpts {
+	return func(opts *gridOpts) {
+		opts.skipEmpty = enable
+	}
+}
+
+// SliceGrid describes a sprite sheet without metadata as a grid of cells,
+// the returned atlas info can be exported or unpacked with UnpackAtlas.
+// Sprites are named <image name>_<index>.png, the index of the cell in row-major order.
+//
+// Example:
+//
+//	atlasInfo, err := SliceGrid("./sheet.png", WithCellSize(32, 32), WithSpacing(1), WithSkipEmpty(true))
+func SliceGrid(imgPath string, fn ...GridOpts) (*model.AtlasInfo, error) {
+	img, err := utils.LoadImg(imgPath)
+	if err != nil {
+		return nil, err
+	}
+	baseName := filepath.Base(imgPath)
+	atlas, err := sliceGrid(img, strings.TrimSuffix(baseName, filepath.Ext(baseName)), fn...)
+	if err != nil {
+		return nil, err
+	}
+	atlas.Name = baseName
+	return &model.AtlasInfo{
+		Meta:    getMateData(),
+		Atlases: []model.Atlas{atlas},
+	}, nil
+}
+
+func sliceGrid(img image.Image, name string, fn ...GridOpts) (model.Atlas, error) {
+	opts := &gridOpts{}
+	for _, f := range fn {
+		f(opts)
+	}
+	if opts.margin < 0 || opts.spacing < 0 {
+		return model.Atlas{}, errors.New("margin and spacing must not be negative")
+	}
+	bounds := img.Bounds()
+	// the size available for cells and spacing
+	w := bounds.Dx() - 2*opts.margin
+	h := bounds.Dy() - 2*opts.margin
+	cols, rows := opts.cols, opts.rows
+	cellW, cellH := opts.cellW, opts.cellH
+	switch {
+	case cellW > 0 && cellH > 0:
+		cols = (w + opts.spacing) / (cellW + opts.spacing)
+		rows = (h + opts.spacing) / (cellH + opts.spacing)
+	case cols > 0 && rows > 0:
+		cellW = (w - (cols-1)*opts.spacing) / cols
+		cellH = (h - (rows-1)*opts.spacing) / rows
+	default:
+		return model.Atlas{}, errors.New("cell size or columns and rows must be greater than 0")
+	}
+	if cols <= 0 || rows <= 0 || cellW <= 0 || cellH <= 0 {
+		return model.Atlas{}, fmt.Errorf("image of %dx%d is too small for the grid", bounds.Dx(), bounds.Dy())
+	}
+
+	atlas := model.Atlas{
+		Size: model.Size{W: bounds.Dx(), H: bounds.Dy()},
+	}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			x := opts.margin + col*(cellW+opts.spacing)
+			y := opts.margin + row*(cellH+opts.spacing)
+			cell := image.Rect(x, y, x+cellW, y+cellH).Add(bounds.Min)
+			if opts.skipEmpty && isTransparent(img, cell) {
+				continue
+			}
+			atlas.Sprites = append(atlas.Sprites, model.Sprite{
+				FileName: fmt.Sprintf("%s_%d.png", name, row*cols+col),
+				Frame:    model.NewRectByPosAndSize(x, y, cellW, cellH),
+				SrcRect:  model.Size{W: cellW, H: cellH},
+			})
+		}
+	}
+	return atlas, nil
+}
+
+// isTransparent reports whether every pixel of r is fully transparent.
+func isTransparent(img image.Image, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
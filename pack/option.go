@@ -16,13 +16,21 @@ type Options struct {
 	allowRotate bool      // allow rotation
 
 	//----atlas----
-	name       string // atlas name
-	sort       bool   // sorting by file name
-	trim       bool   // trim transparent pixels from the image
-	tolerance  uint8  // tolerance for trimming transparency pixels 0-255
-	sameDetect bool   // same detection
-	powerOfTwo bool   // the atlas pixels are fixed to a power of 2
-	imgExt     string // image format
+	name          string   // atlas name
+	sort          bool     // sorting by file name
+	trim          bool     // trim transparent pixels from the image
+	trimMode      TrimMode // how the transparent pixels are removed
+	trimMargin    int      // transparent pixels kept around the opaque pixels when trimming
+	trimEdges     TrimEdge // edges trimmed
+	tolerance     uint8    // tolerance for trimming transparency pixels 0-255
+	sameDetect    bool     // same detection
+	sameTransform bool     // same detection of flipped and rotated sprites
+	powerOfTwo    bool     // the atlas pixels are fixed to a power of 2
+	imgExt        string   // image format
+	polygon       int      // maximum vertices of the polygon outlines, 0 disables them
+	//----animation----
+	animations  bool   // detect the animations from the sprite names
+	animPattern string // regular expression of the animation frame names, DefaultAnimationPattern if empty
 	//----validate----
 	err error
 }
@@ -40,6 +48,7 @@ func NewOptions() *Options {
 		sort:        true,
 		allowRotate: false,
 		trim:        false,
+		trimEdges:   TrimAllEdges,
 		tolerance:   0,
 		sameDetect:  false,
 		powerOfTwo:  false,
@@ -125,6 +134,11 @@ func (b *Options) Algorithm(algo Algorithm) *Options {
 	return b
 }
 
+// PackingAlgorithm returns the packing algorithm set, e.g. to check the export formats before packing.
+func (b *Options) PackingAlgorithm() Algorithm {
+	return b.algorithm
+}
+
 // Heuristic sets the heuristic of the atlas.
 // If the heuristic is not valid, it will be set to BestShortSideFit.
 // It is valid only when the algorithm is AlgoMaxRects.
@@ -167,6 +181,46 @@ func (b *Options) Trim(enable bool) *Options {
 	return b
 }
 
+// TrimMode sets how the transparent pixels are removed, it is valid only when trimming is enabled.
+// If the mode is not valid, it will be set to TrimModeTrim.
+func (b *Options) TrimMode(mode TrimMode) *Options {
+	if b.err != nil {
+		return b
+	}
+	if mode < TrimModeTrim || mode >= MaxTrimModeIndex {
+		mode = TrimModeTrim
+	}
+	b.trimMode = mode
+	return b
+}
+
+// TrimMargin keeps at least margin transparent pixels around the opaque pixels when trimming,
+// within the source image.
+func (b *Options) TrimMargin(margin int) *Options {
+	if b.err != nil {
+		return b
+	}
+	if margin < 0 {
+		b.err = errors.New("trim margin must be greater than or equal to 0")
+		return b
+	}
+	b.trimMargin = margin
+	return b
+}
+
+// TrimEdges sets the edges trimmed, e.g. all but TrimBottom to keep the feet of the characters grounded.
+func (b *Options) TrimEdges(edges TrimEdge) *Options {
+	if b.err != nil {
+		return b
+	}
+	if edges&^TrimAllEdges != 0 {
+		b.err = errors.New("invalid trim edges")
+		return b
+	}
+	b.trimEdges = edges
+	return b
+}
+
 // Tolerance sets the tolerance of trimming transparency pixels.
 func (b *Options) Tolerance(tolerance int) *Options {
 	if b.err != nil {
@@ -189,7 +243,8 @@ func (b *Options) AutoSize(enable bool) *Options {
 	return b
 }
 
-// SameDetect sets the same detection of the atlas.
+// SameDetect packs the sprites with the same pixels once, trimmed if trimming is enabled,
+// the duplicates share the frame of the packed sprite.
 func (b *Options) SameDetect(enable bool) *Options {
 	if b.err != nil {
 		return b
@@ -198,6 +253,30 @@ func (b *Options) SameDetect(enable bool) *Options {
 	return b
 }
 
+// SameTransform also detects the flipped and rotated duplicates when SameDetect is enabled,
+// the Transform of the duplicate sprites gives their pixels from the packed sprite.
+func (b *Options) SameTransform(enable bool) *Options {
+	if b.err != nil {
+		return b
+	}
+	b.sameTransform = enable
+	return b
+}
+
+// Polygon computes the polygon outline of the opaque pixels of the sprites with at most maxVertices vertices,
+// exported as the vertices and triangles of the sprites to reduce overdraw. 0 disables it.
+func (b *Options) Polygon(maxVertices int) *Options {
+	if b.err != nil {
+		return b
+	}
+	if maxVertices != 0 && maxVertices < 4 {
+		b.err = errors.New("polygon vertices must be 0 or at least 4")
+		return b
+	}
+	b.polygon = maxVertices
+	return b
+}
+
 // PowerOfTwo sets the power of two of the atlas.
 // The atlas pixels are fixed to a power of 2.
 func (b *Options) PowerOfTwo(enable bool) *Options {
@@ -208,6 +287,30 @@ func (b *Options) PowerOfTwo(enable bool) *Options {
 	return b
 }
 
+// Animations detects the animations from the sprite names, e.g. run_01..run_08,
+// the fps, loop and direction are read from the AnimationConfigFile in the input directory if any.
+func (b *Options) Animations(enable bool) *Options {
+	if b.err != nil {
+		return b
+	}
+	b.animations = enable
+	return b
+}
+
+// AnimationPattern sets the regular expression of the animation frame names without extension,
+// its first group is the name of the animation. DefaultAnimationPattern is used if empty.
+func (b *Options) AnimationPattern(pattern string) *Options {
+	if b.err != nil {
+		return b
+	}
+	if _, err := compileAnimationPattern(pattern); err != nil {
+		b.err = err
+		return b
+	}
+	b.animPattern = pattern
+	return b
+}
+
 // Validate validates the options.
 // If the options are invalid, it will return an error.
 func (b *Options) Validate() (*Options, error) {
@@ -9,7 +9,9 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -23,10 +25,12 @@ const (
 )
 
 type Packer struct {
-	algo           algo     // interface algo
-	option         *Options // Options for packing
-	sameDetectInfo utils.SameDetectInfo
-	inputDir       string // input path
+	algo       algo                 // interface algo
+	option     *Options             // Options for packing
+	inputDir   string               // input path
+	frames     map[string]animFrame // frames of the animated inputs by sprite path
+	duplicates map[string]duplicate // duplicate sprites by path
+	dupesOf    map[string][]string  // paths of the duplicates by packed sprite path
 }
 
 func NewPacker(option *Options) *Packer {
@@ -36,6 +40,8 @@ func NewPacker(option *Options) *Packer {
 		p.algo = &algoSkyline{}
 	case AlgoMaxRects:
 		p.algo = &algoMaxrects{}
+	case AlgoPolygon:
+		p.algo = &algoPolygon{}
 	default:
 		p.algo = &algoBasic{}
 	}
@@ -103,14 +109,24 @@ func (p *Packer) PackSprites(input string) (*model.AtlasInfo, []image.Image, err
 	if err != nil {
 		return nil, nil, err
 	}
-
-	if p.option.sameDetect {
-		spritePaths, p.sameDetectInfo, _ = utils.FindDuplicateFiles(spritePaths)
-	}
+	// the animation config is not a sprite
+	spritePaths = slices.DeleteFunc(spritePaths, func(path string) bool {
+		return filepath.Base(path) == AnimationConfigFile
+	})
 
 	// save input dir
 	p.inputDir = input
 
+	// every frame of the animated gif and png inputs is a sprite
+	spritePaths, err = p.expandAnimations(spritePaths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.option.sameDetect {
+		spritePaths = p.findDuplicates(spritePaths)
+	}
+
 	// create meta
 	meta := getMateData()
 
@@ -121,6 +137,10 @@ func (p *Packer) PackSprites(input string) (*model.AtlasInfo, []image.Image, err
 	}
 	// get image rects and src rects and trimmed rects
 	reqRects, srcRects, trimmedRectMap := p.getImageRects(spritePaths)
+	polygons := p.spritePolygons(spritePaths)
+	if algo, ok := p.algo.(*algoPolygon); ok {
+		algo.masks = p.spriteMasks(spritePaths, trimmedRectMap, polygons)
+	}
 
 	// pack rects
 	bins := p.PackRect(reqRects)
@@ -175,25 +195,47 @@ func (p *Packer) PackSprites(input string) (*model.AtlasInfo, []image.Image, err
 				TrimmedRect: trimmedRectMap[rect.Id],
 				Rotated:     rect.IsRotated,
 				Trimmed:     p.option.trim,
+				Duration:    p.frames[spritePaths[rect.Id]].duration,
+			}
+			if poly, ok := polygons[rect.Id]; ok {
+				sprite.Vertices = poly.vertices
+				sprite.Triangles = poly.triangles
 			}
 			atlas.Sprites = append(atlas.Sprites, sprite)
 
 			// if same detect
-			// try to find the same file in the same directory
-			if p.option.sameDetect {
-				if dupPaths, ok := p.sameDetectInfo.BaseToDupesName[baseName]; ok {
-					//fmt.Printf("Found duplicate files: %v\n", dupPaths)
-					for _, dupPath := range dupPaths {
-						s := sprite.Clone()
-						s.FileName = dupPath
-						atlas.Sprites = append(atlas.Sprites, s)
-					}
+			// the duplicates share the frame of the packed sprite
+			for _, dupPath := range p.dupesOf[spritePaths[rect.Id]] {
+				dup := p.duplicates[dupPath]
+				s := sprite.Clone()
+				s.FileName = filepath.Base(dupPath)
+				s.SrcRect = dup.srcSize
+				s.TrimmedRect = dup.trimRect
+				s.Transform = dup.transform
+				s.AliasOf = sprite.FileName
+				// the outline moves with the trimmed pixels, transformed duplicates have none
+				if s.Transform != "" {
+					s.Vertices, s.Triangles = nil, nil
+				}
+				for v := range s.Vertices {
+					s.Vertices[v].X += s.TrimmedRect.X - sprite.TrimmedRect.X
+					s.Vertices[v].Y += s.TrimmedRect.Y - sprite.TrimmedRect.Y
 				}
+				s.Duration = p.frames[dupPath].duration
+				atlas.Sprites = append(atlas.Sprites, s)
 			}
 		}
 
 		spriteAtlas.Atlases = append(spriteAtlas.Atlases, atlas)
 	}
+	if p.option.animations {
+		if spriteAtlas.Animations, err = DetectAnimations(spriteAtlas, p.option.animPattern); err != nil {
+			return nil, nil, err
+		}
+		if err = applyAnimationConfig(spriteAtlas.Animations, filepath.Join(input, AnimationConfigFile)); err != nil {
+			return nil, nil, err
+		}
+	}
 	images, err := p.createAtlasImages(spriteAtlas)
 	if err != nil {
 		return spriteAtlas, nil, err
@@ -222,7 +264,11 @@ func (p *Packer) packInBins(reqRects []model.Rect) []model.Bin {
 		// calculates the total area of the packed rectangle
 		totalArea := 0
 		for _, rect := range packedRects {
-			totalArea += rect.W * rect.H
+			if algo, ok := p.algo.(usedAreaAlgo); ok {
+				totalArea += algo.usedArea(rect)
+			} else {
+				totalArea += rect.W * rect.H
+			}
 		}
 
 		// If there are no unpacked rectangles and autosize is enabled, try optimizing the bin size
@@ -284,27 +330,17 @@ func (p *Packer) getImageRects(filePaths []string) ([]model.Rect, []model.Size,
 	srcRects := make([]model.Size, len(filePaths))
 	trimmedRectMap := make(map[int]model.Rect)
 	for i, fileName := range filePaths {
-		file, err := os.Open(fileName)
-		if err != nil {
-			continue // Skip unreadable files
-		}
-		//if i == 1 || i == 2 {
-		//	// continue may cause an empty rect to be passed in, resulting in an extra rect with ID default of 0
-		//	// so use reqRects.append replace reqRects[i]
-		//	// fix on 2025/4.14
-		//	continue
-		//}
 		if p.option.trim {
-			src, _, err := image.Decode(file)
-			file.Close()
+			// the sprites are cropped by the crop modes, see loadSprite
+			src, err := p.loadSprite(fileName)
 			if err != nil {
-				continue // Skip non-image files
+				continue // Skip unreadable or non-image files
 			}
 			srcSize := model.Size{
 				W: src.Bounds().Dx(),
 				H: src.Bounds().Dy(),
 			}
-			trimRect := utils.GetOpaqueBounds(src, p.option.tolerance)
+			trimRect := p.trimRect(src)
 			trimmedRect := model.NewRectByPosAndSize(
 				trimRect.Min.X,
 				trimRect.Min.Y,
@@ -314,19 +350,34 @@ func (p *Packer) getImageRects(filePaths []string) ([]model.Rect, []model.Size,
 			srcRects[i] = srcSize
 			reqRects = append(reqRects, model.NewRectBySizeAndId(trimRect.Dx(), trimRect.Dy(), i))
 			trimmedRectMap[i] = trimmedRect
-		} else {
-			cfg, _, err := image.DecodeConfig(file)
-			file.Close()
-			if err != nil {
-				continue // Skip non-image files
-			}
-			srcSize := model.Size{
-				W: cfg.Width,
-				H: cfg.Height,
-			}
-			srcRects[i] = srcSize
-			reqRects = append(reqRects, model.NewRectBySizeAndId(cfg.Width, cfg.Height, i))
+			continue
 		}
+		if frame, ok := p.frames[fileName]; ok {
+			srcRects[i] = model.Size{W: frame.img.Bounds().Dx(), H: frame.img.Bounds().Dy()}
+			reqRects = append(reqRects, model.NewRectBySizeAndId(srcRects[i].W, srcRects[i].H, i))
+			continue
+		}
+		file, err := os.Open(fileName)
+		if err != nil {
+			continue // Skip unreadable files
+		}
+		//if i == 1 || i == 2 {
+		//	// continue may cause an empty rect to be passed in, resulting in an extra rect with ID default of 0
+		//	// so use reqRects.append replace reqRects[i]
+		//	// fix on 2025/4.14
+		//	continue
+		//}
+		cfg, _, err := image.DecodeConfig(file)
+		file.Close()
+		if err != nil {
+			continue // Skip non-image files
+		}
+		srcSize := model.Size{
+			W: cfg.Width,
+			H: cfg.Height,
+		}
+		srcRects[i] = srcSize
+		reqRects = append(reqRects, model.NewRectBySizeAndId(cfg.Width, cfg.Height, i))
 	}
 
 	return reqRects, srcRects, trimmedRectMap
@@ -346,18 +397,19 @@ func (p *Packer) createAtlasImages(atlas *model.AtlasInfo) ([]image.Image, error
 				Y: trimmedRect.Y,
 			}
 			// if same detect
-			if p.option.sameDetect {
-
-				if _, ok := p.sameDetectInfo.DupeToBaseName[sprite.FileName]; ok {
-					//fmt.Printf("same detect %s \n", sprite.FileName)
-					continue
-				}
+			if sprite.AliasOf != "" {
+				continue
 			}
 
-			spriteImg, err := utils.LoadImg(filepath.Join(p.inputDir, sprite.FileName))
+			spriteImg, err := p.loadSprite(filepath.Join(p.inputDir, sprite.FileName))
 			if err != nil {
 				return nil, err
 			}
+			// the nested sprites only draw the pixels they occupy, the sprites without polygon their whole rect
+			var mask image.Image
+			if p.option.algorithm == AlgoPolygon && len(sprite.Vertices) > 0 {
+				mask = spriteAtlasMask(spriteImg, sprite, p.option.tolerance)
+			}
 			// if rotated
 			if sprite.Rotated {
 				spriteImg = utils.Rotate270(spriteImg)
@@ -368,13 +420,67 @@ func (p *Packer) createAtlasImages(atlas *model.AtlasInfo) ([]image.Image, error
 				srcLeftTopPoint.Y = newY
 			}
 			ditPosition := sprite.Frame.ToImageRect()
-			draw.Draw(atlasImg, ditPosition, spriteImg, srcLeftTopPoint, draw.Src)
+			if mask != nil {
+				draw.DrawMask(atlasImg, ditPosition, spriteImg, srcLeftTopPoint, mask, ditPosition.Min, draw.Over)
+			} else {
+				draw.Draw(atlasImg, ditPosition, spriteImg, srcLeftTopPoint, draw.Src)
+			}
 			atlasImages[i] = atlasImg
 		}
 	}
 	return atlasImages, nil
 }
 
+// expandAnimations replaces the animated gif and png inputs by a path per frame, named <file>_<index>.png,
+// the frames are kept in memory. It fails if a frame name is the name of an input or of another frame.
+func (p *Packer) expandAnimations(spritePaths []string) ([]string, error) {
+	p.frames = make(map[string]animFrame)
+	paths := make([]string, 0, len(spritePaths))
+	inputs := make(map[string]bool, len(spritePaths))
+	for _, spritePath := range spritePaths {
+		inputs[spritePath] = true
+	}
+	for _, spritePath := range spritePaths {
+		ext := strings.ToLower(filepath.Ext(spritePath))
+		if ext != ".gif" && (ext != ".png" || !utils.IsAPNG(spritePath)) {
+			paths = append(paths, spritePath)
+			continue
+		}
+		frames, delays, err := utils.LoadFrames(spritePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load frames of %s: %v", spritePath, err)
+		}
+		if len(frames) == 1 {
+			paths = append(paths, spritePath)
+			continue
+		}
+		base := strings.TrimSuffix(spritePath, filepath.Ext(spritePath))
+		for i, frame := range frames {
+			framePath := fmt.Sprintf("%s_%d.png", base, i)
+			// the frame would share its sprite name with an input or another frame
+			if _, ok := p.frames[framePath]; ok || inputs[framePath] {
+				return nil, fmt.Errorf("frame %d of %s is named %s like another sprite", i, spritePath, filepath.Base(framePath))
+			}
+			p.frames[framePath] = animFrame{img: frame, duration: delays[i]}
+			paths = append(paths, framePath)
+		}
+	}
+	return paths, nil
+}
+
+// loadSprite loads a sprite image, from the frames of the animated inputs or from the file,
+// cropped if a crop trim mode is enabled.
+func (p *Packer) loadSprite(spritePath string) (image.Image, error) {
+	if frame, ok := p.frames[spritePath]; ok {
+		return p.cropSprite(frame.img), nil
+	}
+	img, err := utils.LoadImg(spritePath)
+	if err != nil {
+		return nil, err
+	}
+	return p.cropSprite(img), nil
+}
+
 func getMateData() model.Meta {
 	return model.Meta{
 		Repo:      Repo,
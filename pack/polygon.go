@@ -0,0 +1,476 @@
+package pack
+
+import (
+	"cmp"
+	"github.com/91xusir/spritepacker/model"
+	"image"
+	"math"
+	"slices"
+)
+
+// polygon is the outline of the opaque pixels of a sprite and its triangulation.
+type polygon struct {
+	vertices  []model.Point // pixel corners in the source sprite
+	triangles [][3]int      // indexes of the vertices
+}
+
+// spritePolygons computes the polygon outlines of the sprites by index if enabled or needed by AlgoPolygon.
+func (p *Packer) spritePolygons(spritePaths []string) map[int]*polygon {
+	polygons := make(map[int]*polygon)
+	maxVertices := p.option.polygon
+	if maxVertices == 0 && p.option.algorithm == AlgoPolygon {
+		maxVertices = polygonAlgoVertices
+	}
+	if maxVertices == 0 {
+		return polygons
+	}
+	for i, spritePath := range spritePaths {
+		img, err := p.loadSprite(spritePath)
+		if err != nil {
+			continue // Skip non-image files
+		}
+		if poly := spritePolygon(img, p.option.tolerance, maxVertices); poly != nil {
+			polygons[i] = poly
+		}
+	}
+	return polygons
+}
+
+// alphaMask is the opaque pixels of an image, with an alpha above the tolerance.
+type alphaMask struct {
+	w, h   int
+	opaque []bool
+}
+
+func newAlphaMask(img image.Image, tolerance uint8) *alphaMask {
+	b := img.Bounds()
+	m := &alphaMask{w: b.Dx(), h: b.Dy(), opaque: make([]bool, b.Dx()*b.Dy())}
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			_, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			m.opaque[y*m.w+x] = uint8(a>>8) > tolerance
+		}
+	}
+	return m
+}
+
+func (m *alphaMask) at(x, y int) bool {
+	return x >= 0 && y >= 0 && x < m.w && y < m.h && m.opaque[y*m.w+x]
+}
+
+// spritePolygon returns the outline of the opaque pixels of the image with at most maxVertices vertices,
+// containing the corners of every opaque pixel. The outline is traced with marching squares and simplified
+// with Douglas-Peucker, the convex hull or the bounding box are used when they are smaller or the outline
+// can not be simplified enough. It returns nil if the image has no opaque pixel.
+func spritePolygon(img image.Image, tolerance uint8, maxVertices int) *polygon {
+	mask := newAlphaMask(img, tolerance)
+	// the corners of the pixels on the border of the opaque area must be covered
+	var corners []model.Point
+	seen := make(map[model.Point]bool)
+	minX, minY, maxX, maxY := mask.w, mask.h, 0, 0
+	for y := 0; y < mask.h; y++ {
+		for x := 0; x < mask.w; x++ {
+			if !mask.at(x, y) || mask.at(x-1, y) && mask.at(x+1, y) && mask.at(x, y-1) && mask.at(x, y+1) {
+				continue
+			}
+			minX, minY, maxX, maxY = min(minX, x), min(minY, y), max(maxX, x+1), max(maxY, y+1)
+			for _, c := range []model.Point{{X: x, Y: y}, {X: x + 1, Y: y}, {X: x, Y: y + 1}, {X: x + 1, Y: y + 1}} {
+				if !seen[c] {
+					seen[c] = true
+					corners = append(corners, c)
+				}
+			}
+		}
+	}
+	if len(corners) == 0 {
+		return nil
+	}
+
+	bounds := image.Rect(minX, minY, maxX, maxY)
+	var candidates [][]model.Point
+	if outline := traceOutline(mask); outline != nil {
+		for _, kept := range simplifyToBudget(outline, maxVertices) {
+			// the edges of the simplified outline cut the skipped corners, one more pixel if rounding cuts them still
+			for _, margin := range []float64{0, 1} {
+				if expanded := expandOutline(outline, kept, margin, bounds); coversPoints(expanded, corners) {
+					candidates = append(candidates, expanded)
+					break
+				}
+			}
+		}
+	}
+	if hull := convexHull(corners); len(hull) <= maxVertices {
+		candidates = append(candidates, hull)
+	}
+	candidates = append(candidates, []model.Point{
+		{X: minX, Y: minY}, {X: maxX, Y: minY}, {X: maxX, Y: maxY}, {X: minX, Y: maxY},
+	})
+	slices.SortStableFunc(candidates, func(a, b []model.Point) int {
+		return cmp.Compare(polygonArea(a), polygonArea(b))
+	})
+	for _, vertices := range candidates {
+		if triangles := triangulate(vertices); triangles != nil {
+			return &polygon{vertices: vertices, triangles: triangles}
+		}
+	}
+	return nil
+}
+
+// traceOutline walks the outer border of the opaque pixels along the pixel edges with marching squares,
+// returning the corners where the direction changes. It returns nil if the opaque pixels are not 4-connected.
+func traceOutline(mask *alphaMask) []model.Point {
+	start := slices.Index(mask.opaque, true)
+	if start < 0 || countRegions(mask) != 1 {
+		return nil
+	}
+	sx, sy := start%mask.w, start/mask.w
+	x, y := sx, sy
+	dx, dy := 0, 0
+	var points []model.Point
+	for steps := 0; steps <= 2*(mask.w+1)*(mask.h+1); steps++ {
+		state := 0
+		if mask.at(x-1, y-1) {
+			state |= 1
+		}
+		if mask.at(x, y-1) {
+			state |= 2
+		}
+		if mask.at(x-1, y) {
+			state |= 4
+		}
+		if mask.at(x, y) {
+			state |= 8
+		}
+		// walk with the opaque pixels on the left, the diagonal pixels of saddles are not connected
+		var nx, ny int
+		switch state {
+		case 1, 5, 13:
+			nx, ny = 0, -1
+		case 8, 10, 11:
+			nx, ny = 0, 1
+		case 4, 12, 14:
+			nx, ny = -1, 0
+		case 2, 3, 7:
+			nx, ny = 1, 0
+		case 6:
+			if dy == -1 {
+				nx, ny = -1, 0
+			} else {
+				nx, ny = 1, 0
+			}
+		case 9:
+			if dx == 1 {
+				nx, ny = 0, -1
+			} else {
+				nx, ny = 0, 1
+			}
+		default:
+			return nil
+		}
+		if nx != dx || ny != dy {
+			points = append(points, model.Point{X: x, Y: y})
+		}
+		x, y = x+nx, y+ny
+		dx, dy = nx, ny
+		if x == sx && y == sy {
+			return points
+		}
+	}
+	return nil
+}
+
+// countRegions counts the 4-connected regions of opaque pixels.
+func countRegions(mask *alphaMask) int {
+	visited := make([]bool, len(mask.opaque))
+	regions := 0
+	var stack []int
+	for i, opaque := range mask.opaque {
+		if !opaque || visited[i] {
+			continue
+		}
+		regions++
+		visited[i] = true
+		stack = append(stack[:0], i)
+		for len(stack) > 0 {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := j%mask.w, j/mask.w
+			for _, n := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				k := n[1]*mask.w + n[0]
+				if mask.at(n[0], n[1]) && !visited[k] {
+					visited[k] = true
+					stack = append(stack, k)
+				}
+			}
+		}
+	}
+	return regions
+}
+
+// simplifyToBudget simplifies the closed outline with Douglas-Peucker, bisecting the tolerance to keep
+// at most maxVertices vertices. It returns the indexes of the kept vertices of every simplification
+// within the budget, the tightest outline is not always the one with the most vertices.
+func simplifyToBudget(outline []model.Point, maxVertices int) [][]int {
+	if kept := simplifyClosed(outline, 0); len(kept) <= maxVertices {
+		return [][]int{kept}
+	}
+	lo, hi := 0.0, 0.0
+	for _, p := range outline {
+		hi = math.Max(hi, math.Hypot(float64(p.X-outline[0].X), float64(p.Y-outline[0].Y)))
+	}
+	var results [][]int
+	for i := 0; i < 24; i++ {
+		epsilon := (lo + hi) / 2
+		if kept := simplifyClosed(outline, epsilon); len(kept) <= maxVertices {
+			if !slices.ContainsFunc(results, func(r []int) bool { return slices.Equal(r, kept) }) {
+				results = append(results, kept)
+			}
+			hi = epsilon
+		} else {
+			lo = epsilon
+		}
+	}
+	return results
+}
+
+// simplifyClosed simplifies a closed polygon with Douglas-Peucker, split at its first vertex and the farthest one.
+func simplifyClosed(points []model.Point, epsilon float64) []int {
+	far, farDist := 0, -1.0
+	for i, p := range points {
+		if d := math.Hypot(float64(p.X-points[0].X), float64(p.Y-points[0].Y)); d > farDist {
+			far, farDist = i, d
+		}
+	}
+	indexes := make([]int, len(points)+1)
+	for i := range points {
+		indexes[i] = i
+	}
+	// the second half closes the polygon back to the first vertex
+	first := douglasPeucker(points, indexes[:far+1], epsilon)
+	second := douglasPeucker(points, indexes[far:], epsilon)
+	return append(first, second[1:len(second)-1]...)
+}
+
+// douglasPeucker simplifies an open polyline given by indexes of points, keeping its end points.
+func douglasPeucker(points []model.Point, indexes []int, epsilon float64) []int {
+	if len(indexes) < 3 {
+		return slices.Clone(indexes)
+	}
+	a, b := points[indexes[0]], points[indexes[len(indexes)-1]]
+	index, dist := 0, -1.0
+	for i := 1; i < len(indexes)-1; i++ {
+		if d := segmentDistance(points[indexes[i]], a, b); d > dist {
+			index, dist = i, d
+		}
+	}
+	if dist <= epsilon {
+		return []int{indexes[0], indexes[len(indexes)-1]}
+	}
+	left := douglasPeucker(points, indexes[:index+1], epsilon)
+	right := douglasPeucker(points, indexes[index:], epsilon)
+	return append(left[:len(left)-1], right...)
+}
+
+// expandOutline moves the edges of the simplified outline outwards until the outline vertices they skip
+// are inside, plus margin pixels, and returns the corners of the moved edges rounded to pixels and clamped to bounds.
+func expandOutline(outline []model.Point, kept []int, margin float64, bounds image.Rectangle) []model.Point {
+	n := len(kept)
+	if n < 3 {
+		return nil
+	}
+	vertices := make([]model.Point, n)
+	for i, k := range kept {
+		vertices[i] = outline[k]
+	}
+	// outwards is on the right of the edges of a polygon with a positive area
+	orientation := 1.0
+	if signedArea(vertices) < 0 {
+		orientation = -1
+	}
+	type line struct{ x, y, dx, dy float64 }
+	lines := make([]line, n)
+	for i := range kept {
+		a, b := outline[kept[i]], outline[kept[(i+1)%n]]
+		dx, dy := float64(b.X-a.X), float64(b.Y-a.Y)
+		length := math.Hypot(dx, dy)
+		nx, ny := dy/length*orientation, -dx/length*orientation
+		offset := 0.0
+		for j := kept[i]; j != kept[(i+1)%n]; j = (j + 1) % len(outline) {
+			p := outline[j]
+			offset = math.Max(offset, float64(p.X-a.X)*nx+float64(p.Y-a.Y)*ny)
+		}
+		offset += margin
+		lines[i] = line{float64(a.X) + nx*offset, float64(a.Y) + ny*offset, dx, dy}
+	}
+	for i, l2 := range lines {
+		l1 := lines[(i+n-1)%n]
+		den := l1.dx*l2.dy - l1.dy*l2.dx
+		if den == 0 {
+			return nil
+		}
+		t := ((l2.x-l1.x)*l2.dy - (l2.y-l1.y)*l2.dx) / den
+		vertices[i] = model.Point{
+			X: min(max(int(math.Round(l1.x+t*l1.dx)), bounds.Min.X), bounds.Max.X),
+			Y: min(max(int(math.Round(l1.y+t*l1.dy)), bounds.Min.Y), bounds.Max.Y),
+		}
+	}
+	return removeCollinear(slices.Compact(vertices))
+}
+
+// segmentDistance returns the distance between p and the segment ab.
+func segmentDistance(p, a, b model.Point) float64 {
+	abx, aby := float64(b.X-a.X), float64(b.Y-a.Y)
+	apx, apy := float64(p.X-a.X), float64(p.Y-a.Y)
+	length := abx*abx + aby*aby
+	if length == 0 {
+		return math.Hypot(apx, apy)
+	}
+	t := math.Max(0, math.Min(1, (apx*abx+apy*aby)/length))
+	return math.Hypot(apx-t*abx, apy-t*aby)
+}
+
+// convexHull returns the convex hull of the points with the monotone chain algorithm.
+func convexHull(points []model.Point) []model.Point {
+	sorted := slices.Clone(points)
+	slices.SortFunc(sorted, func(a, b model.Point) int {
+		if a.X != b.X {
+			return a.X - b.X
+		}
+		return a.Y - b.Y
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+	hull := make([]model.Point, 0, 2*len(sorted))
+	for _, p := range sorted {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(sorted) - 2; i >= 0; i-- {
+		p := sorted[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// cross returns the cross product of ab and ac, positive if c is on the left of ab with y up.
+func cross(a, b, c model.Point) int {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// signedArea returns twice the signed area of the polygon.
+func signedArea(points []model.Point) int {
+	area := 0
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area
+}
+
+func polygonArea(points []model.Point) float64 {
+	return math.Abs(float64(signedArea(points))) / 2
+}
+
+// coversPoints reports whether every point is inside the polygon or on its border.
+func coversPoints(polygon []model.Point, points []model.Point) bool {
+	if len(polygon) < 3 {
+		return false
+	}
+	for _, p := range points {
+		if !containsPoint(polygon, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPoint(polygon []model.Point, p model.Point) bool {
+	inside := false
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		if cross(a, b, p) == 0 && min(a.X, b.X) <= p.X && p.X <= max(a.X, b.X) &&
+			min(a.Y, b.Y) <= p.Y && p.Y <= max(a.Y, b.Y) {
+			return true
+		}
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := float64(a.X) + float64(p.Y-a.Y)*float64(b.X-a.X)/float64(b.Y-a.Y)
+			if float64(p.X) < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// removeCollinear removes the vertices on the line between their neighbours.
+func removeCollinear(polygon []model.Point) []model.Point {
+	result := make([]model.Point, 0, len(polygon))
+	for i := range polygon {
+		prev, next := polygon[(i+len(polygon)-1)%len(polygon)], polygon[(i+1)%len(polygon)]
+		if cross(prev, polygon[i], next) != 0 {
+			result = append(result, polygon[i])
+		}
+	}
+	return result
+}
+
+// triangulate triangulates the polygon by ear clipping, it returns nil if the polygon is not simple.
+func triangulate(polygon []model.Point) [][3]int {
+	if len(polygon) < 3 {
+		return nil
+	}
+	indexes := make([]int, len(polygon))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	// ears turn in the direction of the polygon
+	orientation := 1
+	if signedArea(polygon) < 0 {
+		orientation = -1
+	}
+	var triangles [][3]int
+	for len(indexes) > 3 {
+		found := false
+		for i := range indexes {
+			a := indexes[(i+len(indexes)-1)%len(indexes)]
+			b := indexes[i]
+			c := indexes[(i+1)%len(indexes)]
+			if cross(polygon[a], polygon[b], polygon[c])*orientation <= 0 {
+				continue
+			}
+			ear := true
+			for _, j := range indexes {
+				if j != a && j != b && j != c && polygon[j] != polygon[a] && polygon[j] != polygon[b] &&
+					polygon[j] != polygon[c] && inTriangle(polygon[j], polygon[a], polygon[b], polygon[c]) {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				triangles = append(triangles, [3]int{a, b, c})
+				indexes = slices.Delete(indexes, i, i+1)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return append(triangles, [3]int{indexes[0], indexes[1], indexes[2]})
+}
+
+// inTriangle reports whether p is inside the triangle abc or on its border.
+func inTriangle(p, a, b, c model.Point) bool {
+	d1, d2, d3 := cross(a, b, p), cross(b, c, p), cross(c, a, p)
+	negative := d1 < 0 || d2 < 0 || d3 < 0
+	positive := d1 > 0 || d2 > 0 || d3 > 0
+	return !(negative && positive)
+}
@@ -0,0 +1,130 @@
+package pack
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"github.com/91xusir/spritepacker/model"
+	"github.com/91xusir/spritepacker/utils"
+	"image"
+	"image/draw"
+)
+
+// duplicate is a sprite whose pixels are the pixels of a packed sprite, possibly transformed.
+type duplicate struct {
+	base      string     // path of the packed sprite
+	transform string     // transform of the packed sprite pixels, empty if identical
+	srcSize   model.Size // source size of the duplicate
+	trimRect  model.Rect // trimmed rect of the duplicate, zero if not trimmed
+}
+
+// sameTransforms are the transforms tried to find duplicates, identical pixels first.
+var sameTransforms = []string{
+	"", model.FlipX, model.FlipY, model.Rotate180,
+	model.Rotate90, model.Rotate270, model.Transpose, model.Transverse,
+}
+
+// findDuplicates removes the sprites whose pixels, trimmed if trimming is enabled, are the pixels of a previous sprite.
+// Flipped and rotated duplicates are detected too if enabled. The duplicates are kept in p.duplicates.
+func (p *Packer) findDuplicates(spritePaths []string) []string {
+	p.duplicates = make(map[string]duplicate)
+	p.dupesOf = make(map[string][]string)
+	transforms := sameTransforms[:1]
+	if p.option.sameTransform {
+		transforms = sameTransforms
+	}
+	hashes := make(map[[md5.Size]byte]string)
+	uniques := make([]string, 0, len(spritePaths))
+	for _, spritePath := range spritePaths {
+		img, err := p.loadSprite(spritePath)
+		if err != nil {
+			// not an image, skipped later
+			uniques = append(uniques, spritePath)
+			continue
+		}
+		content := img.Bounds()
+		var trimRect model.Rect
+		if p.option.trim {
+			rect := p.trimRect(img)
+			trimRect = model.NewRectByPosAndSize(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+			content = rect.Add(img.Bounds().Min)
+		}
+		pixels := contentPixels(img, content)
+		found := false
+		for _, transform := range transforms {
+			hash := pixelHash(transformImage(pixels, transform))
+			if base, ok := hashes[hash]; ok {
+				// the transformed duplicate is the base, so the duplicate is the inversely transformed base
+				p.duplicates[spritePath] = duplicate{
+					base:      base,
+					transform: inverseTransform(transform),
+					srcSize:   model.Size{W: img.Bounds().Dx(), H: img.Bounds().Dy()},
+					trimRect:  trimRect,
+				}
+				p.dupesOf[base] = append(p.dupesOf[base], spritePath)
+				found = true
+				break
+			}
+		}
+		if !found {
+			hashes[pixelHash(pixels)] = spritePath
+			uniques = append(uniques, spritePath)
+		}
+	}
+	return uniques
+}
+
+// contentPixels copies the rect of the image, with the color of the transparent pixels cleared.
+func contentPixels(img image.Image, rect image.Rectangle) *image.NRGBA {
+	pixels := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(pixels, pixels.Bounds(), img, rect.Min, draw.Src)
+	for i := 0; i < len(pixels.Pix); i += 4 {
+		if pixels.Pix[i+3] == 0 {
+			pixels.Pix[i], pixels.Pix[i+1], pixels.Pix[i+2] = 0, 0, 0
+		}
+	}
+	return pixels
+}
+
+// pixelHash hashes the size and the pixels of the image.
+func pixelHash(img *image.NRGBA) [md5.Size]byte {
+	hash := md5.New()
+	_ = binary.Write(hash, binary.LittleEndian, [2]int32{int32(img.Rect.Dx()), int32(img.Rect.Dy())})
+	for y := 0; y < img.Rect.Dy(); y++ {
+		hash.Write(img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()*4])
+	}
+	var sum [md5.Size]byte
+	copy(sum[:], hash.Sum(nil))
+	return sum
+}
+
+// transformImage applies a sprite transform, see model.FlipX, to the image.
+func transformImage(img *image.NRGBA, transform string) *image.NRGBA {
+	switch transform {
+	case model.FlipX:
+		return utils.FlipH(img)
+	case model.FlipY:
+		return utils.FlipV(img)
+	case model.Rotate90:
+		return utils.Rotate270(img) // utils rotates counter-clockwise
+	case model.Rotate180:
+		return utils.Rotate180(img)
+	case model.Rotate270:
+		return utils.Rotate90(img)
+	case model.Transpose:
+		return utils.Transpose(img)
+	case model.Transverse:
+		return utils.Transverse(img)
+	}
+	return img
+}
+
+// inverseTransform returns the transform undoing the given transform.
+func inverseTransform(transform string) string {
+	switch transform {
+	case model.Rotate90:
+		return model.Rotate270
+	case model.Rotate270:
+		return model.Rotate90
+	}
+	return transform
+}
@@ -0,0 +1,91 @@
+package pack
+
+import (
+	"fmt"
+	"github.com/91xusir/spritepacker/utils"
+	"image"
+	"image/draw"
+	"strings"
+)
+
+// TrimMode defines how the transparent pixels of the sprites are removed when trimming is enabled.
+type TrimMode int
+
+const (
+	// TrimModeTrim keeps the source size of the sprites, the trimmed pixels are an offset in the source.
+	TrimModeTrim TrimMode = iota
+	// TrimModeCropKeepPos crops the sprites after their opaque pixels, keeping the offset of the opaque pixels.
+	TrimModeCropKeepPos
+	// TrimModeCropFlush crops the sprites to their opaque pixels, without offset.
+	TrimModeCropFlush
+	MaxTrimModeIndex
+)
+
+// TrimEdge is a set of edges of the sprites, e.g. TrimLeft | TrimTop | TrimRight.
+type TrimEdge int
+
+const (
+	TrimLeft TrimEdge = 1 << iota
+	TrimTop
+	TrimRight
+	TrimBottom
+	TrimAllEdges = TrimLeft | TrimTop | TrimRight | TrimBottom
+)
+
+// ParseTrimEdges parses the edges from their initials, e.g. "ltr" for all the edges except the bottom.
+func ParseTrimEdges(edges string) (TrimEdge, error) {
+	var result TrimEdge
+	for _, c := range strings.ToLower(edges) {
+		switch c {
+		case 'l':
+			result |= TrimLeft
+		case 't':
+			result |= TrimTop
+		case 'r':
+			result |= TrimRight
+		case 'b':
+			result |= TrimBottom
+		default:
+			return 0, fmt.Errorf("invalid trim edge %q in %q, expected l, t, r or b", c, edges)
+		}
+	}
+	return result, nil
+}
+
+// trimRect returns the rect of the pixels kept by trimming the image, relative to its bounds:
+// the opaque pixels with the trim margin, the untrimmed edges are kept.
+func (p *Packer) trimRect(img image.Image) image.Rectangle {
+	bounds := img.Bounds()
+	full := image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	rect := utils.GetOpaqueBounds(img, p.option.tolerance).Sub(bounds.Min)
+	rect = rect.Inset(-p.option.trimMargin).Intersect(full)
+	edges := p.option.trimEdges
+	if edges&TrimLeft == 0 {
+		rect.Min.X = 0
+	}
+	if edges&TrimTop == 0 {
+		rect.Min.Y = 0
+	}
+	if edges&TrimRight == 0 {
+		rect.Max.X = full.Max.X
+	}
+	if edges&TrimBottom == 0 {
+		rect.Max.Y = full.Max.Y
+	}
+	return rect
+}
+
+// cropSprite crops the image as the source of the sprite in the crop modes,
+// trimming the cropped image keeps the same pixels.
+func (p *Packer) cropSprite(img image.Image) image.Image {
+	if !p.option.trim || p.option.trimMode == TrimModeTrim {
+		return img
+	}
+	rect := p.trimRect(img)
+	if p.option.trimMode == TrimModeCropKeepPos {
+		rect.Min = image.Point{}
+	}
+	cropped := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(cropped, cropped.Bounds(), img, img.Bounds().Min.Add(rect.Min), draw.Src)
+	return cropped
+}
@@ -8,16 +8,39 @@ import (
 	"image"
 	"image/draw"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"text/template"
 )
 
 type unpackedOpts struct {
 	atlasImgPath string
 	outputPath   string
+	glob         string // glob on the sprite names
+	regex        string // regular expression on the sprite names
+	atlases      []int  // indexes of the atlases to unpack, all when empty
+	nameTemplate string // text/template of the output names
+	trimmedOnly  bool   // save the trimmed sprites without restoring the source size
+	imgExt       string // output image format, the extension of the sprite names by default
+	animExt      string // ".gif" or ".png" to save animations instead of sprites
+	frameDelay   int    // delay of the animation frames in milliseconds, the sprite durations by default
 }
 type UnpackOpts func(*unpackedOpts)
 
+// unpackName is the data of the output name template.
+type unpackName struct {
+	FileName   string // sprite name in the atlas info, e.g. "player/run_01.png"
+	Dir        string // directory of the sprite name, e.g. "player", empty if none
+	Name       string // base name without extension, e.g. "run_01"
+	Ext        string // extension of the sprite name, e.g. ".png"
+	Index      int    // index of the sprite in its atlas
+	Atlas      string // atlas image name without extension
+	AtlasIndex int    // index of the atlas
+}
+
 func WithImgInput(atlasImgPath string) UnpackOpts {
 	if atlasImgPath == "" {
 		return func(opts *unpackedOpts) {
@@ -39,69 +62,195 @@ func WithOutput(outputPath string) UnpackOpts {
 	}
 }
 
-func UnpackSprites(infoPath string, fn ...UnpackOpts) error {
-	opts := &unpackedOpts{
-		atlasImgPath: filepath.Dir(infoPath),
-		outputPath:   filepath.Dir(infoPath),
+// WithFilter unpacks the sprites whose name matches the glob pattern, e.g. "run_*.png".
+func WithFilter(glob string) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		opts.glob = glob
 	}
-	for _, f := range fn {
-		f(opts)
+}
+
+// WithRegexFilter unpacks the sprites whose name matches the regular expression.
+func WithRegexFilter(regex string) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		opts.regex = regex
 	}
-	// make sure outputPath exists
-	if err := os.MkdirAll(opts.outputPath, os.ModePerm); err != nil {
-		return err
+}
+
+// WithAtlases unpacks the sprites of the atlases of the given indexes.
+func WithAtlases(indexes ...int) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		opts.atlases = indexes
+	}
+}
+
+// WithNameTemplate sets the text/template of the output names relative to the output directory,
+// executed with the fields FileName, Dir, Name, Ext, Index, Atlas and AtlasIndex,
+// e.g. "{{.Atlas}}/{{.Name}}". The extension of the sprite name is added when the result has none.
+// The base name of the sprite is used by default.
+func WithNameTemplate(nameTemplate string) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		opts.nameTemplate = nameTemplate
 	}
+}
+
+// WithTrimmedOnly saves trimmed sprites as stored in the atlas, without restoring their transparent margins.
+func WithTrimmedOnly(enable bool) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		opts.trimmedOnly = enable
+	}
+}
+
+// WithImgFormat sets the output image format, e.g. "webp", replacing the extension of the output names.
+func WithImgFormat(ext string) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		if ext != "" {
+			opts.imgExt = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+		}
+	}
+}
+
+// WithAnimation saves animated "gif" or "apng" images of the animations instead of the sprites.
+// The animations of the atlas info are used, or the sprites grouped by name prefix, e.g. run_01..run_08.
+func WithAnimation(format string) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+		case "":
+			opts.animExt = ""
+		case "gif":
+			opts.animExt = ".gif"
+		case "apng", "png":
+			opts.animExt = ".png"
+		default:
+			opts.animExt = format
+		}
+	}
+}
+
+// WithFrameDelay sets the delay of the animation frames in milliseconds,
+// the durations of the sprites or 100ms are used by default.
+func WithFrameDelay(delay int) UnpackOpts {
+	return func(opts *unpackedOpts) {
+		opts.frameDelay = delay
+	}
+}
+
+func UnpackSprites(infoPath string, fn ...UnpackOpts) error {
 	exporter := export.NewExportManager().Init()
 	atlasInfo, err := exporter.Import(infoPath)
 	if err != nil {
 		return err
 	}
-	err = UnpackAtlas(atlasInfo, *opts)
-	if err != nil {
-		return err
-	}
-	return nil
+	// the atlas images and the sprites default to the directory of the info file
+	dir := filepath.Dir(infoPath)
+	return UnpackAtlas(atlasInfo, append([]UnpackOpts{WithImgInput(dir), WithOutput(dir)}, fn...)...)
 }
 
-func UnpackAtlas(atlasInfo *model.AtlasInfo, opts unpackedOpts) error {
+// UnpackAtlas saves the sprites of the atlas info, the atlas images are searched by name
+// in the image input directory, the current directory by default.
+func UnpackAtlas(atlasInfo *model.AtlasInfo, fn ...UnpackOpts) error {
+	opts := &unpackedOpts{
+		atlasImgPath: ".",
+		outputPath:   ".",
+	}
+	for _, f := range fn {
+		f(opts)
+	}
+	if opts.glob != "" {
+		if _, err := path.Match(opts.glob, ""); err != nil {
+			return fmt.Errorf("invalid filter %q: %v", opts.glob, err)
+		}
+	}
+	var re *regexp.Regexp
+	if opts.regex != "" {
+		var err error
+		if re, err = regexp.Compile(opts.regex); err != nil {
+			return fmt.Errorf("invalid regex filter %q: %v", opts.regex, err)
+		}
+	}
+	var nameTmpl *template.Template
+	if opts.nameTemplate != "" {
+		var err error
+		if nameTmpl, err = template.New("name").Parse(opts.nameTemplate); err != nil {
+			return fmt.Errorf("invalid name template: %v", err)
+		}
+	}
+	if opts.animExt != "" && opts.animExt != ".gif" && opts.animExt != ".png" {
+		return fmt.Errorf("unsupported animation format %s", opts.animExt)
+	}
+	if opts.imgExt != "" && !isImageExt(opts.imgExt) {
+		return fmt.Errorf("unsupported image format %s", opts.imgExt)
+	}
+	for _, index := range opts.atlases {
+		if index < 0 || index >= len(atlasInfo.Atlases) {
+			return fmt.Errorf("atlas index %d out of range, %d atlases", index, len(atlasInfo.Atlases))
+		}
+	}
+	// make sure outputPath exists
+	if err := os.MkdirAll(opts.outputPath, os.ModePerm); err != nil {
+		return err
+	}
 	baseNames := make([]string, len(atlasInfo.Atlases))
 	for i := range atlasInfo.Atlases {
 		baseNames[i] = strings.TrimSuffix(filepath.Base(atlasInfo.Atlases[i].Name), filepath.Ext(atlasInfo.Atlases[i].Name))
 	}
-	extStr := []string{".png", ".jpg", ".jpeg", ".bmp", ".tiff", ".webp"}
-	for i, baseName := range baseNames {
-		var imgFilePath string
-		found := false
-		for _, ext := range extStr {
-			tryPath := filepath.Join(opts.atlasImgPath, baseName+ext)
-			if _, err := os.Stat(tryPath); err == nil {
-				imgFilePath = tryPath
-				found = true
-				break
+	// atlas and sprite indexes of the packed sprites by name,
+	// the aliases are restored from the frame of their sprite in any atlas
+	packed := make(map[string][2]int)
+	for i, atlas := range atlasInfo.Atlases {
+		for j, sprite := range atlas.Sprites {
+			if _, ok := packed[sprite.FileName]; !ok && sprite.AliasOf == "" {
+				packed[sprite.FileName] = [2]int{i, j}
 			}
 		}
-		if !found {
-			return fmt.Errorf("image file for atlas %s not found", baseName)
-		}
-		atlasImg, err := utils.LoadImg(imgFilePath)
-		if err != nil {
-			return fmt.Errorf("failed to load image %s: %v", imgFilePath, err)
+	}
+	atlasImgs := make(map[int]image.Image)
+	// restored sprites by name in animation mode
+	frames := make(map[string]animFrame)
+	for i := range baseNames {
+		if len(opts.atlases) > 0 && !slices.Contains(opts.atlases, i) {
+			continue
 		}
-		for j := range atlasInfo.Atlases[i].Sprites {
-			sprite := atlasInfo.Atlases[i].Sprites[j]
-			outputPath := filepath.Join(opts.outputPath, filepath.Base(sprite.FileName))
-			subImg := image.NewNRGBA(image.Rect(0, 0, sprite.Frame.W, sprite.Frame.H))
+		for j, sprite := range atlasInfo.Atlases[i].Sprites {
+			name := filepath.ToSlash(sprite.FileName)
+			if opts.glob != "" {
+				if ok, _ := path.Match(opts.glob, name); !ok {
+					continue
+				}
+			}
+			if re != nil && !re.MatchString(name) {
+				continue
+			}
+			// an alias without its sprite keeps its own frame
+			frame, frameAtlas := sprite, i
+			if ref, ok := packed[sprite.AliasOf]; ok && sprite.AliasOf != "" {
+				frame, frameAtlas = atlasInfo.Atlases[ref[0]].Sprites[ref[1]], ref[0]
+			}
+			atlasImg, ok := atlasImgs[frameAtlas]
+			if !ok {
+				var err error
+				if atlasImg, err = loadAtlasImg(opts.atlasImgPath, baseNames[frameAtlas]); err != nil {
+					return err
+				}
+				atlasImgs[frameAtlas] = atlasImg
+			}
+			subImg := image.NewNRGBA(image.Rect(0, 0, frame.Frame.W, frame.Frame.H))
 			srcLeftTopPoint := image.Point{
-				X: sprite.Frame.X,
-				Y: sprite.Frame.Y,
+				X: frame.Frame.X,
+				Y: frame.Frame.Y,
 			}
 			draw.Draw(subImg, subImg.Bounds(), atlasImg, srcLeftTopPoint, draw.Src)
 			// if rotated
-			if sprite.Rotated {
+			if frame.Rotated {
 				subImg = utils.Rotate90(subImg)
 			}
-			// if trimmed
-			if sprite.Trimmed {
+			// the pixels out of the polygon may be of the sprites nested by AlgoPolygon
+			if len(frame.Vertices) > 0 {
+				clipToPolygon(subImg, frame)
+			}
+			// if a flipped or rotated duplicate
+			subImg = transformImage(subImg, sprite.Transform)
+			// if trimmed, animation frames are always restored to align them
+			if sprite.Trimmed && (!opts.trimmedOnly || opts.animExt != "") {
 				img := image.NewNRGBA(image.Rect(0, 0, sprite.SrcRect.W, sprite.SrcRect.H))
 				destRect := image.Rect(
 					sprite.TrimmedRect.X,
@@ -112,11 +261,96 @@ func UnpackAtlas(atlasInfo *model.AtlasInfo, opts unpackedOpts) error {
 				draw.Draw(img, destRect, subImg, image.Point{}, draw.Src)
 				subImg = img
 			}
-			err := utils.SaveImgByExt(outputPath, subImg)
+			if opts.animExt != "" {
+				frames[sprite.FileName] = animFrame{img: subImg, duration: sprite.Duration}
+				continue
+			}
+			name, err := outputName(nameTmpl, opts.imgExt, unpackName{
+				FileName:   sprite.FileName,
+				Dir:        strings.TrimSuffix(filepath.Dir(filepath.ToSlash(sprite.FileName)), "."),
+				Name:       strings.TrimSuffix(filepath.Base(sprite.FileName), filepath.Ext(sprite.FileName)),
+				Ext:        filepath.Ext(sprite.FileName),
+				Index:      j,
+				Atlas:      baseNames[i],
+				AtlasIndex: i,
+			})
+			if err != nil {
+				return fmt.Errorf("sprite %s: %v", sprite.FileName, err)
+			}
+			outputPath := filepath.Join(opts.outputPath, name)
+			err = utils.SaveImgByExt(outputPath, subImg)
 			if err != nil {
 				return fmt.Errorf("failed to save image %s: %v", outputPath, err)
 			}
 		}
 	}
+	if opts.animExt != "" {
+		return saveAnimations(atlasInfo, frames, opts)
+	}
 	return nil
 }
+
+// clipToPolygon clears the pixels of the unrotated frame image out of the polygon of the sprite.
+func clipToPolygon(img *image.NRGBA, sprite model.Sprite) {
+	content := img.Bounds()
+	if sprite.Trimmed {
+		content = content.Add(image.Pt(sprite.TrimmedRect.X, sprite.TrimmedRect.Y))
+	}
+	mask := polygonMask(sprite.Vertices, content)
+	for y := 0; y < mask.h; y++ {
+		for x := 0; x < mask.w; x++ {
+			if !mask.at(x, y) {
+				clear(img.Pix[y*img.Stride+x*4 : y*img.Stride+x*4+4])
+			}
+		}
+	}
+}
+
+// loadAtlasImg loads the atlas image of the base name in the directory, whatever its image format.
+func loadAtlasImg(dir, baseName string) (image.Image, error) {
+	for _, ext := range imageExts {
+		imgFilePath := filepath.Join(dir, baseName+ext)
+		if _, err := os.Stat(imgFilePath); err != nil {
+			continue
+		}
+		atlasImg, err := utils.LoadImg(imgFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load image %s: %v", imgFilePath, err)
+		}
+		return atlasImg, nil
+	}
+	return nil, fmt.Errorf("image file for atlas %s not found", baseName)
+}
+
+var imageExts = []string{".png", ".jpg", ".jpeg", ".bmp", ".tiff", ".webp"}
+
+func isImageExt(ext string) bool {
+	return slices.Contains(imageExts, strings.ToLower(ext))
+}
+
+// outputName returns the output path of a sprite relative to the output directory.
+func outputName(nameTmpl *template.Template, imgExt string, data unpackName) (string, error) {
+	name := filepath.Base(data.FileName)
+	if nameTmpl != nil {
+		var sb strings.Builder
+		if err := nameTmpl.Execute(&sb, data); err != nil {
+			return "", err
+		}
+		name = strings.TrimSpace(sb.String())
+		if name == "" {
+			return "", fmt.Errorf("empty output name")
+		}
+	}
+	if !isImageExt(filepath.Ext(name)) {
+		// add the extension of the sprite name, png if it is not an image
+		ext := data.Ext
+		if !isImageExt(ext) {
+			ext = ".png"
+		}
+		name += ext
+	}
+	if imgExt != "" {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + imgExt
+	}
+	return filepath.FromSlash(name), nil
+}
@@ -2,9 +2,13 @@ package spritepacker
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/91xusir/spritepacker/export"
 	"github.com/91xusir/spritepacker/model"
+	"github.com/91xusir/spritepacker/pack"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -96,3 +100,707 @@ export default ATLAS_DATA;
 		t.Fatalf("%v", err)
 	}
 }
+
+// sampleAtlasInfo returns an atlas with a plain, a trimmed and a trimmed+rotated sprite.
+func sampleAtlasInfo() *model.AtlasInfo {
+	return &model.AtlasInfo{
+		Meta: model.Meta{
+			Repo:      pack.Repo,
+			Format:    pack.Format,
+			Version:   "1.0.0",
+			Timestamp: "2025-01-01 00:00:00",
+		},
+		Atlases: []model.Atlas{
+			{
+				Name: "atlas.png",
+				Size: model.Size{W: 128, H: 64},
+				Sprites: []model.Sprite{
+					{
+						FileName: "plain.png",
+						Frame:    model.NewRectByPosAndSize(0, 0, 32, 32),
+						SrcRect:  model.Size{W: 32, H: 32},
+					},
+					{
+						FileName:    "trimmed.png",
+						Frame:       model.NewRectByPosAndSize(32, 0, 20, 10),
+						SrcRect:     model.Size{W: 32, H: 32},
+						TrimmedRect: model.NewRectByPosAndSize(3, 5, 20, 10),
+						Trimmed:     true,
+					},
+					{
+						FileName:    "rotated.png",
+						Frame:       model.NewRect(52, 0, 12, 30, 0),
+						SrcRect:     model.Size{W: 40, H: 16},
+						TrimmedRect: model.NewRectByPosAndSize(4, 1, 30, 12),
+						Rotated:     true,
+						Trimmed:     true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// assertSameSprites compares the geometry of the sprites of two atlases.
+func assertSameSprites(t *testing.T, want, got model.Atlas) {
+	t.Helper()
+	if len(want.Sprites) != len(got.Sprites) {
+		t.Fatalf("sprite count: want %d, got %d", len(want.Sprites), len(got.Sprites))
+	}
+	for i, w := range want.Sprites {
+		g := got.Sprites[i]
+		if w.FileName != g.FileName ||
+			w.Frame.Point != g.Frame.Point || w.Frame.Size != g.Frame.Size ||
+			w.SrcRect != g.SrcRect || w.Rotated != g.Rotated || w.Trimmed != g.Trimmed ||
+			w.TrimmedRect.Point != g.TrimmedRect.Point || w.TrimmedRect.Size != g.TrimmedRect.Size ||
+			w.AliasOf != g.AliasOf {
+			t.Errorf("sprite %d:\nwant %+v\ngot  %+v", i, w, g)
+		}
+	}
+}
+
+func TestPlistRoundTrip(t *testing.T) {
+	exporter := &export.PlistExporter{}
+	want := sampleAtlasInfo()
+	data, err := exporter.Export(want)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	got, err := exporter.Import(data)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if got.Atlases[0].Name != "atlas.png" || got.Atlases[0].Size != want.Atlases[0].Size {
+		t.Errorf("atlas: got %s %v", got.Atlases[0].Name, got.Atlases[0].Size)
+	}
+	// plist dicts are sorted by name on import
+	w := want.Atlases[0]
+	w.Sprites = []model.Sprite{w.Sprites[0], w.Sprites[2], w.Sprites[1]}
+	assertSameSprites(t, w, got.Atlases[0])
+}
+
+func TestSparrowRoundTrip(t *testing.T) {
+	exporter := &export.SparrowExporter{}
+	want := sampleAtlasInfo()
+	data, err := exporter.Export(want)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	got, err := exporter.Import(data)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if got.Atlases[0].Name != "atlas.png" || got.Atlases[0].Size != want.Atlases[0].Size {
+		t.Errorf("atlas: got %s %v", got.Atlases[0].Name, got.Atlases[0].Size)
+	}
+	assertSameSprites(t, want.Atlases[0], got.Atlases[0])
+}
+
+func TestYamlTomlRoundTrip(t *testing.T) {
+	want := sampleAtlasInfo()
+	want.Atlases[0].Sprites[1].Pivot = &model.Pivot{X: 0.5, Y: 1}
+	want.Atlases[0].Sprites[0].Border = model.Border{L: 4, T: 4, R: 4, B: 4}
+	jsonData, err := (&export.JsonExporter{}).Export(want)
+	if err != nil {
+		t.Fatalf("Export json failed: %v", err)
+	}
+	fromJson, err := (&export.JsonExporter{}).Import(jsonData)
+	if err != nil {
+		t.Fatalf("Import json failed: %v", err)
+	}
+	for _, exporter := range []export.Exporter{&export.YamlExporter{}, &export.TomlExporter{}} {
+		data, err := exporter.Export(fromJson)
+		if err != nil {
+			t.Fatalf("%T Export failed: %v", exporter, err)
+		}
+		if !exporter.(export.Detector).Detect(data) {
+			t.Errorf("%T does not detect its own output", exporter)
+		}
+		got, err := exporter.Import(data)
+		if err != nil {
+			t.Fatalf("%T Import failed: %v", exporter, err)
+		}
+		if got.Meta != want.Meta || got.Atlases[0].Name != "atlas.png" || got.Atlases[0].Size != want.Atlases[0].Size {
+			t.Errorf("%T: got %+v %s %v", exporter, got.Meta, got.Atlases[0].Name, got.Atlases[0].Size)
+		}
+		assertSameSprites(t, want.Atlases[0], got.Atlases[0])
+		sprites := got.Atlases[0].Sprites
+		if sprites[1].Pivot == nil || *sprites[1].Pivot != *want.Atlases[0].Sprites[1].Pivot || sprites[0].Pivot != nil {
+			t.Errorf("%T pivots: got %v %v", exporter, sprites[0].Pivot, sprites[1].Pivot)
+		}
+		if sprites[0].Border != want.Atlases[0].Sprites[0].Border {
+			t.Errorf("%T border: got %+v", exporter, sprites[0].Border)
+		}
+		// the same schema as json
+		again, _ := (&export.JsonExporter{}).Export(got)
+		if string(again) != string(jsonData) {
+			t.Errorf("%T: json of the imported atlas differs\n%s\n%s", exporter, jsonData, again)
+		}
+	}
+}
+
+func TestPlistAliases(t *testing.T) {
+	atlasInfo := sampleAtlasInfo()
+	sprites := atlasInfo.Atlases[0].Sprites
+	alias := sprites[1].Clone()
+	alias.FileName = "trimmed_copy.png"
+	alias.AliasOf = "trimmed.png"
+	flipped := sprites[2].Clone()
+	flipped.FileName = "rotated_flip.png"
+	flipped.AliasOf = "rotated.png"
+	flipped.Transform = model.FlipX
+	flipped.TrimmedRect.X = 6
+	atlasInfo.Atlases[0].Sprites = append(sprites, flipped, alias)
+
+	// the formats with frames only would show the flipped alias unflipped
+	manager := export.NewExportManager().Init()
+	for _, ext := range []string{".plist", ".xml", ".css", ".html", ".meta", ".go", ".h", ".lua", ".tpsheet"} {
+		exporter, _ := manager.Get(ext)
+		if _, err := exporter.Export(atlasInfo); err == nil {
+			t.Errorf("%s: expected an error for the transformed alias", ext)
+		}
+	}
+
+	atlasInfo.Atlases[0].Sprites = append(sprites, alias)
+	exporter := &export.PlistExporter{}
+	data, err := exporter.Export(atlasInfo)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<array>\n\t\t\t\t\t<string>trimmed_copy.png</string>\n\t\t\t\t</array>") {
+		t.Errorf("alias missing in the frame of its sprite:\n%s", data)
+	}
+	got, err := exporter.Import(data)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	want := sampleAtlasInfo().Atlases[0]
+	want.Sprites = []model.Sprite{want.Sprites[0], want.Sprites[2], want.Sprites[1], alias}
+	assertSameSprites(t, want, got.Atlases[0])
+}
+
+func TestTexturePackerExport(t *testing.T) {
+	atlasInfo := sampleAtlasInfo()
+	rotated := &atlasInfo.Atlases[0].Sprites[2]
+	// the corners of the trimmed rect in the source sprite
+	rotated.Vertices = []model.Point{{X: 4, Y: 1}, {X: 34, Y: 1}, {X: 34, Y: 13}, {X: 4, Y: 13}}
+	rotated.Triangles = [][3]int{{0, 1, 2}, {0, 2, 3}}
+
+	data, err := json.Marshal(atlasInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"vertices":[{"x":4,"y":1},`) || !strings.Contains(string(data), `"triangles":[[0,1,2],[0,2,3]]`) {
+		t.Errorf("json: missing polygon in %s", data)
+	}
+
+	exporter := &export.JsonExporter{TexturePacker: true}
+	data, err = exporter.Export(atlasInfo)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var sheet struct {
+		Frames []struct {
+			Frame      map[string]int `json:"frame"`
+			VerticesUV [][2]int       `json:"verticesUV"`
+		} `json:"frames"`
+	}
+	if err = json.Unmarshal(data, &sheet); err != nil {
+		t.Fatal(err)
+	}
+	// the frame size is before rotation and the vertices are rotated clockwise in the atlas
+	frame := sheet.Frames[2]
+	if frame.Frame["w"] != 30 || frame.Frame["h"] != 12 ||
+		fmt.Sprint(frame.VerticesUV) != "[[64 0] [64 30] [52 30] [52 0]]" || sheet.Frames[0].VerticesUV != nil {
+		t.Errorf("got frame %v verticesUV %v", frame.Frame, frame.VerticesUV)
+	}
+
+	got, err := exporter.Import(data)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	assertSameSprites(t, atlasInfo.Atlases[0], got.Atlases[0])
+	if fmt.Sprint(got.Atlases[0].Sprites[2].Vertices, got.Atlases[0].Sprites[2].Triangles) !=
+		fmt.Sprint(rotated.Vertices, rotated.Triangles) {
+		t.Errorf("got polygon %v %v", got.Atlases[0].Sprites[2].Vertices, got.Atlases[0].Sprites[2].Triangles)
+	}
+}
+
+func TestPolygonFormats(t *testing.T) {
+	manager := export.NewExportManager().Init()
+	manager.RegisterTemplate(".js", "{{.Meta.Version}}", nil)
+	for _, ext := range []string{".json", ".yaml", ".toml", ".js"} {
+		if !manager.Polygons(ext) {
+			t.Errorf("%s: expected the polygons to be exported", ext)
+		}
+	}
+	for _, ext := range []string{".tpsheet", ".plist", ".xml", ".css", ".meta", ".go", ".h", ".lua", ".defold.atlas", ".unknown"} {
+		if manager.Polygons(ext) {
+			t.Errorf("%s: the format has no polygons", ext)
+		}
+	}
+}
+
+func TestPerAtlasExport(t *testing.T) {
+	atlasInfo := sampleAtlasInfo()
+	second := atlasInfo.Atlases[0]
+	second.Name = "atlas_1.png"
+	atlasInfo.Atlases[0].Name = "atlas_0.png"
+	atlasInfo.Atlases = append(atlasInfo.Atlases, second)
+
+	dir := t.TempDir()
+	manager := export.NewExportManager().Init()
+	if err := manager.Export(filepath.Join(dir, "atlas.xml"), atlasInfo); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	for i, atlas := range atlasInfo.Atlases {
+		got, err := manager.Import(filepath.Join(dir, fmt.Sprintf("atlas_%d.xml", i)))
+		if err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if len(got.Atlases) != 1 || got.Atlases[0].Name != atlas.Name {
+			t.Errorf("atlas %d: got %+v", i, got.Atlases)
+		}
+	}
+}
+
+func TestCssExport(t *testing.T) {
+	exporter := &export.CssExporter{Scale: 2}
+	data, err := exporter.Export(sampleAtlasInfo())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	css := string(data)
+	for _, want := range []string{
+		".sprite-trimmed {\n\twidth: 10px;\n\theight: 5px;",
+		"background-position: -16px 0;",
+		"background-size: 64px 32px;",
+		".sprite-rotated::before",
+		"transform: translateY(6px) rotate(-90deg);",
+	} {
+		if !strings.Contains(css, want) {
+			t.Errorf("css does not contain %q:\n%s", want, css)
+		}
+	}
+	// names sanitized to the same class, and a name equal to a numbered class
+	atlasInfo := sampleAtlasInfo()
+	atlasInfo.Atlases[0].Sprites = nil
+	for _, name := range []string{"a-b.png", "a b.png", "a.b.png", "a-b-2.png"} {
+		atlasInfo.Atlases[0].Sprites = append(atlasInfo.Atlases[0].Sprites, model.Sprite{FileName: name, Frame: model.NewRectBySize(1, 1)})
+	}
+	if data, err = exporter.Export(atlasInfo); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	for _, class := range []string{".sprite-a-b {", ".sprite-a-b-2 {", ".sprite-a-b-3 {", ".sprite-a-b-2-2 {"} {
+		if strings.Count(string(data), class) != 1 {
+			t.Errorf("class %q not defined once:\n%s", class, data)
+		}
+	}
+}
+
+func TestUnityExport(t *testing.T) {
+	atlasInfo := sampleAtlasInfo()
+	if _, err := (&export.UnityExporter{}).Export(atlasInfo); err == nil {
+		t.Errorf("expected an error for rotated sprites")
+	}
+	atlasInfo.Atlases[0].Sprites = atlasInfo.Atlases[0].Sprites[:2]
+	atlasInfo.Atlases[0].Sprites[1].Pivot = &model.Pivot{X: 0.5, Y: 1}
+
+	dir := t.TempDir()
+	if err := export.NewExportManager().Init().Export(filepath.Join(dir, "atlas.meta"), atlasInfo); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "atlas.png.meta"))
+	if err != nil {
+		t.Fatalf("meta file not written next to the image: %v", err)
+	}
+	meta := string(data)
+	for _, want := range []string{
+		"spriteMode: 2",
+		// trimmed.png: bottom-left origin and the pivot at the bottom of the untrimmed sprite
+		"x: 32\n        y: 54\n        width: 20\n        height: 10",
+		"pivot: {x: 0.65, y: -1.7}",
+	} {
+		if !strings.Contains(meta, want) {
+			t.Errorf("meta does not contain %q:\n%s", want, meta)
+		}
+	}
+}
+
+func TestGoExport(t *testing.T) {
+	exporter := &export.GoExporter{Package: "assets", Embed: true}
+	data, err := exporter.Export(sampleAtlasInfo())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	src := string(data)
+	for _, want := range []string{
+		"package assets",
+		"//go:embed \"atlas.png\"\nvar AtlasImage []byte",
+		"Trimmed = Sprite{Name: \"trimmed.png\", Atlas: 0, Frame: image.Rect(32, 0, 52, 10), Offset: image.Pt(3, 5), Source: image.Pt(32, 32), Rotated: false}",
+		"\"rotated.png\": Rotated,",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("source does not contain %q:\n%s", want, src)
+		}
+	}
+	if _, err := (&export.GoExporter{Package: "my-assets"}).Export(sampleAtlasInfo()); err == nil {
+		t.Errorf("expected an error for an invalid package name")
+	}
+	// a sprite name in two atlases would be a duplicate key of the Sprites map
+	atlasInfo := sampleAtlasInfo()
+	atlasInfo.Atlases = append(atlasInfo.Atlases, atlasInfo.Atlases[0])
+	if _, err := exporter.Export(atlasInfo); err == nil {
+		t.Errorf("expected an error for duplicate sprite names")
+	}
+}
+
+func TestCHeaderExport(t *testing.T) {
+	exporter := &export.CHeaderExporter{Prefix: "ui", Guard: "UI_SPRITES_H"}
+	data, err := exporter.Export(sampleAtlasInfo())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	header := string(data)
+	for _, want := range []string{
+		"#ifndef UI_SPRITES_H",
+		"UI_TRIMMED = 1,",
+		"UI_COUNT\n} ui_id;",
+		"static const ui_frame ui_frames[UI_COUNT]",
+		"{ \"rotated.png\", 0, 52, 0, 12, 30, 4, 1, 40, 16, 1 },",
+	} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header does not contain %q:\n%s", want, header)
+		}
+	}
+}
+
+func TestLuaExport(t *testing.T) {
+	atlasInfo := sampleAtlasInfo()
+	atlasInfo.Atlases[0].Sprites[1].Pivot = &model.Pivot{X: 0.5, Y: 1}
+	data, err := (&export.LuaExporter{}).Export(atlasInfo)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	lua := string(data)
+	for _, want := range []string{
+		"return {",
+		`{ image = "atlas.png", width = 128, height = 64 },`,
+		`["trimmed.png"] = {`,
+		"pivotX = 0.5, pivotY = 1,",
+		"x = 52, y = 0, w = 12, h = 30,\n\t\t\toffsetX = 4, offsetY = 1,\n\t\t\tsourceW = 40, sourceH = 16,\n\t\t\trotated = true,",
+	} {
+		if !strings.Contains(lua, want) {
+			t.Errorf("lua does not contain %q:\n%s", want, lua)
+		}
+	}
+	// a sprite name in two atlases would replace the first sprite of the sprites table
+	atlasInfo.Atlases = append(atlasInfo.Atlases, atlasInfo.Atlases[0])
+	if _, err = (&export.LuaExporter{}).Export(atlasInfo); err == nil {
+		t.Errorf("expected an error for duplicate sprite names")
+	}
+}
+
+func TestDefoldExport(t *testing.T) {
+	data, err := (&export.DefoldExporter{ImagePrefix: "/sprites"}).Export(sampleAtlasInfo())
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	atlas := string(data)
+	for _, want := range []string{
+		"images {\n  image: \"/sprites/plain.png\"\n  sprite_trim_mode: SPRITE_TRIM_MODE_OFF\n}",
+		"images {\n  image: \"/sprites/trimmed.png\"\n  sprite_trim_mode: SPRITE_TRIM_MODE_4\n}",
+		"max_page_width: 0",
+	} {
+		if !strings.Contains(atlas, want) {
+			t.Errorf("atlas does not contain %q:\n%s", want, atlas)
+		}
+	}
+	// .atlas is left to the libGDX and Spine atlases
+	dir := t.TempDir()
+	manager := export.NewExportManager().Init()
+	if err = manager.Export(filepath.Join(dir, "atlas.defold.atlas"), sampleAtlasInfo()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "atlas.defold.atlas")); err != nil {
+		t.Error(err)
+	}
+	if err = manager.Export(filepath.Join(dir, "atlas.atlas"), sampleAtlasInfo()); err == nil {
+		t.Errorf("expected an error for .atlas")
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmplPath := filepath.Join(dir, "names.txt.tmpl")
+	tmpl := `{{range .Atlases}}{{range .Sprites}}{{.FileName}};{{end}}{{end}}`
+	if err := os.WriteFile(tmplPath, []byte(tmpl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manager := export.NewExportManager().Init()
+	exts, err := manager.LoadTemplateDir(dir)
+	if err != nil || len(exts) != 1 || exts[0] != ".txt" {
+		t.Fatalf("LoadTemplateDir: %v %v", exts, err)
+	}
+	if ext, err := manager.LoadTemplate(tmplPath, "names"); err != nil || ext != ".names" {
+		t.Fatalf("LoadTemplate: %v %v", ext, err)
+	}
+	outPath := filepath.Join(dir, "atlas.names")
+	if err := manager.Export(outPath, sampleAtlasInfo()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, _ := os.ReadFile(outPath)
+	if string(data) != "plain.png;trimmed.png;rotated.png;" {
+		t.Errorf("unexpected output %q", data)
+	}
+
+	badPath := filepath.Join(dir, "bad.tmpl")
+	_ = os.WriteFile(badPath, []byte("{{.Atlases"), 0644)
+	if _, err := manager.LoadTemplate(badPath, ".bad"); err == nil {
+		t.Errorf("expected a parse error")
+	}
+	if _, err := manager.LoadTemplate(badPath, ""); err == nil {
+		t.Errorf("expected an error for a template without extension")
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	manager := export.NewExportManager().Init()
+	tmpl := `{{- $atlas := index .Atlases 0}}
+{{- range $atlas.Sprites}}
+{{- $uv := uv .Frame $atlas.Size}}
+{{- $m := margin .}}
+{{- $o := centerOffset .}}
+{{camelCase (trimExt .FileName)}} {{upperSnakeCase (trimExt .FileName)}} {{printf "%.3f %.3f %.3f %.3f" $uv.U0 $uv.V0 $uv.U1 $uv.V1}} {{(size .).W}}x{{(size .).H}} {{$m.L}},{{$m.T}},{{$m.R}},{{$m.B}} {{$o.X}},{{$o.Y}} {{add .Frame.X .Frame.W}} {{divf .Frame.W 8}}
+{{- end}}
+{{json $atlas.Name}} {{luaEscape "a\"b\n"}} {{xmlEscape "<a&b>"}}`
+	manager.RegisterTemplate(".txt", tmpl, nil)
+	dir := t.TempDir()
+	if err := manager.Export(filepath.Join(dir, "atlas.txt"), sampleAtlasInfo()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "atlas.txt"))
+	want := `
+plain PLAIN 0.000 0.000 0.250 0.500 32x32 0,0,0,0 0,0 32 4
+trimmed TRIMMED 0.250 0.000 0.406 0.156 20x10 3,5,9,17 -3,6 52 2.5
+rotated ROTATED 0.406 0.000 0.500 0.469 30x12 4,1,6,3 -1,1 64 1.5
+"atlas.png" a\"b\n &lt;a&amp;b&gt;`
+	if string(data) != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestExporterOptions(t *testing.T) {
+	manager := export.NewExportManager().Init()
+	if err := manager.SetOption(".json", "compact", "true"); err != nil {
+		t.Fatalf("SetOption failed: %v", err)
+	}
+	if err := manager.SetOption(".json", export.OptStripExt, "true"); err != nil {
+		t.Fatalf("SetOption failed: %v", err)
+	}
+	if err := manager.SetOption(".json", "compact", "yes please"); err == nil {
+		t.Errorf("expected an error for an invalid bool")
+	}
+	if err := manager.SetOption(".json", "unknown", "1"); err == nil {
+		t.Errorf("expected an error for an unknown option")
+	}
+	godot, _ := manager.Get(".tpsheet")
+	godot.(*export.GodotExporter).ImagePrefix = "res://sprites/"
+
+	dir := t.TempDir()
+	atlasInfo := sampleAtlasInfo()
+	if err := manager.Export(filepath.Join(dir, "atlas.json"), atlasInfo); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "atlas.json"))
+	if strings.Contains(string(data), "\n") || !strings.Contains(string(data), `"filename":"trimmed",`) {
+		t.Errorf("expected compact json without extensions: %s", data)
+	}
+	if atlasInfo.Atlases[0].Sprites[1].FileName != "trimmed.png" {
+		t.Errorf("stripExt must not modify the exported atlas info")
+	}
+	if err := manager.Export(filepath.Join(dir, "atlas.tpsheet"), atlasInfo); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "atlas.tpsheet"))
+	if !strings.Contains(string(data), `"image": "res://sprites/atlas.png"`) {
+		t.Errorf("expected the image prefix: %s", data)
+	}
+}
+
+func TestImportDetectsFormat(t *testing.T) {
+	dir := t.TempDir()
+	manager := export.NewExportManager().Init()
+	atlasInfo := sampleAtlasInfo()
+	atlasInfo.Atlases[0].Sprites = atlasInfo.Atlases[0].Sprites[:2]
+
+	// a .tpsheet and a plist renamed to .json, our json schema renamed to .txt
+	renamed := map[string]string{"atlas.tpsheet": "tpsheet.json", "atlas.plist": "plist.json", "atlas.json": "json.txt"}
+	for src, dst := range renamed {
+		if err := manager.Export(filepath.Join(dir, src), atlasInfo); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+		if err := os.Rename(filepath.Join(dir, src), filepath.Join(dir, dst)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := manager.Import(filepath.Join(dir, dst))
+		if err != nil {
+			t.Fatalf("Import %s failed: %v", dst, err)
+		}
+		if len(got.Atlases) != 1 || len(got.Atlases[0].Sprites) != 2 {
+			t.Errorf("Import %s: got %+v", dst, got.Atlases)
+		}
+	}
+
+	texturePacker := `{"frames": {"a.png": {"frame": {"x":0,"y":0,"w":1,"h":1}}}, "meta": {"image": "a.png"}}`
+	path := filepath.Join(dir, "texturepacker.json")
+	_ = os.WriteFile(path, []byte(texturePacker), 0644)
+	if got, err := manager.Import(path); err != nil || got.Atlases[0].Sprites[0].FileName != "a.png" {
+		t.Errorf("Import texturepacker.json: got %+v, %v", got, err)
+	}
+
+	path = filepath.Join(dir, "unknown.json")
+	_ = os.WriteFile(path, []byte(`{"sprites": {"a.png": {"x":0,"y":0,"w":1,"h":1}}}`), 0644)
+	if _, err := manager.Import(path); err == nil {
+		t.Errorf("expected an error for an unrecognised format")
+	}
+}
+
+const asepriteSheet = `{ "frames": {
+   "run 0.aseprite": {
+    "frame": { "x": 0, "y": 0, "w": 4, "h": 4 },
+    "rotated": false,
+    "trimmed": false,
+    "spriteSourceSize": { "x": 0, "y": 0, "w": 4, "h": 4 },
+    "sourceSize": { "w": 4, "h": 4 },
+    "duration": 100
+   },
+   "run 1.aseprite": {
+    "frame": { "x": 4, "y": 0, "w": 2, "h": 3 },
+    "rotated": false,
+    "trimmed": true,
+    "spriteSourceSize": { "x": 1, "y": 0, "w": 2, "h": 3 },
+    "sourceSize": { "w": 4, "h": 4 },
+    "duration": 150
+   }
+ },
+ "meta": {
+  "app": "https://www.aseprite.org/",
+  "version": "1.3.7-x64",
+  "image": "run.png",
+  "format": "RGBA8888",
+  "size": { "w": 6, "h": 4 },
+  "scale": "1",
+  "frameTags": [
+   { "name": "run", "from": 0, "to": 1, "direction": "pingpong", "color": "#000000ff" }
+  ],
+  "slices": [
+   { "name": "body", "color": "#0000ffff", "keys": [
+     { "frame": 1, "bounds": {"x": 0, "y": 0, "w": 4, "h": 4 }, "center": {"x": 1, "y": 1, "w": 2, "h": 1 }, "pivot": {"x": 2, "y": 4 } }
+   ]}
+  ]
+ }
+}
+`
+
+func TestAsepriteImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := os.WriteFile(path, []byte(asepriteSheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := export.NewExportManager().Init().Import(path)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	want := model.Atlas{
+		Name: "run.png",
+		Size: model.Size{W: 6, H: 4},
+		Sprites: []model.Sprite{
+			{
+				FileName: "run 0.png",
+				Frame:    model.NewRectByPosAndSize(0, 0, 4, 4),
+				SrcRect:  model.Size{W: 4, H: 4},
+			},
+			{
+				FileName:    "run 1.png",
+				Frame:       model.NewRectByPosAndSize(4, 0, 2, 3),
+				SrcRect:     model.Size{W: 4, H: 4},
+				TrimmedRect: model.NewRectByPosAndSize(1, 0, 2, 3),
+				Trimmed:     true,
+			},
+		},
+	}
+	if got.Atlases[0].Name != want.Name || got.Atlases[0].Size != want.Size {
+		t.Errorf("atlas: got %s %v", got.Atlases[0].Name, got.Atlases[0].Size)
+	}
+	assertSameSprites(t, want, got.Atlases[0])
+	sprites := got.Atlases[0].Sprites
+	if sprites[0].Duration != 100 || sprites[1].Duration != 150 {
+		t.Errorf("durations: got %d %d", sprites[0].Duration, sprites[1].Duration)
+	}
+	// the slice starts on the second frame
+	if sprites[0].Pivot != nil || sprites[0].Border != (model.Border{}) {
+		t.Errorf("first frame: got pivot %v border %+v", sprites[0].Pivot, sprites[0].Border)
+	}
+	if sprites[1].Pivot == nil || *sprites[1].Pivot != (model.Pivot{X: 0.5, Y: 1}) {
+		t.Errorf("pivot: got %v", sprites[1].Pivot)
+	}
+	if sprites[1].Border != (model.Border{L: 1, T: 1, R: 1, B: 2}) {
+		t.Errorf("border: got %+v", sprites[1].Border)
+	}
+	if len(got.Animations) != 1 || got.Animations[0].Name != "run" || got.Animations[0].Direction != model.PingPong ||
+		strings.Join(got.Animations[0].Frames, ",") != "run 0.png,run 1.png" {
+		t.Errorf("animations: got %+v", got.Animations)
+	}
+	// .aseprite is the Aseprite document, the json sheet is only imported from .json
+	if err = export.NewExportManager().Init().Export(filepath.Join(t.TempDir(), "run.aseprite"), got); err == nil {
+		t.Errorf("expected .aseprite not to be a registered format")
+	}
+}
+
+func TestAsepriteRotatedImport(t *testing.T) {
+	// the frame size is the size before rotation, the sprite is stored rotated in the 2x3 atlas
+	sheet := `{"frames": [{"filename": "a.png", "frame": {"x": 0, "y": 0, "w": 3, "h": 2}, "rotated": true,
+		"spriteSourceSize": {"x": 0, "y": 0, "w": 3, "h": 2}, "sourceSize": {"w": 3, "h": 2}, "duration": 100}],
+		"meta": {"app": "https://www.aseprite.org/", "image": "a_sheet.png", "size": {"w": 2, "h": 3}}}`
+	got, err := (&export.JsonExporter{}).Import([]byte(sheet))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	sprite := got.Atlases[0].Sprites[0]
+	want := model.NewRectByPosAndSize(0, 0, 2, 3)
+	want.IsRotated = true
+	if sprite.Frame != want || !sprite.Rotated || sprite.SrcRect != (model.Size{W: 3, H: 2}) {
+		t.Errorf("got frame %+v rotated %v source %v", sprite.Frame, sprite.Rotated, sprite.SrcRect)
+	}
+}
+
+func TestExportAll(t *testing.T) {
+	dir := t.TempDir()
+	manager := export.NewExportManager().Init()
+	base := filepath.Join(dir, "atlas")
+	if err := manager.ExportAll(base, []string{".json", ".unknown"}, sampleAtlasInfo()); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+	if _, err := os.Stat(base + ".json"); !os.IsNotExist(err) {
+		t.Errorf("nothing should be written when a format is not supported")
+	}
+	// the go source fails on duplicate sprite names after the json is rendered
+	duplicates := sampleAtlasInfo()
+	duplicates.Atlases = append(duplicates.Atlases, duplicates.Atlases[0])
+	if err := manager.ExportAll(base, []string{".json", ".go"}, duplicates); err == nil {
+		t.Errorf("expected an error for duplicate sprite names")
+	}
+	if _, err := os.Stat(base + ".json"); !os.IsNotExist(err) {
+		t.Errorf("nothing should be written when a format fails")
+	}
+	exts := []string{".json", ".tpsheet", ".xml"}
+	if err := manager.ExportAll(base, exts, sampleAtlasInfo()); err != nil {
+		t.Fatalf("ExportAll failed: %v", err)
+	}
+	for _, ext := range exts {
+		if _, err := manager.Import(base + ext); err != nil {
+			t.Errorf("Import %s failed: %v", ext, err)
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package spritepacker
 
 import (
+	"fmt"
 	"github.com/91xusir/spritepacker/export"
+	"github.com/91xusir/spritepacker/model"
 	"github.com/91xusir/spritepacker/pack"
 	"github.com/91xusir/spritepacker/utils"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
 	"path/filepath"
 	"testing"
 )
@@ -41,6 +48,749 @@ func TestSpriteUnpack(t *testing.T) {
 	}
 }
 
+func TestAsepriteUnpack(t *testing.T) {
+	dir := t.TempDir()
+	sheet := image.NewNRGBA(image.Rect(0, 0, 6, 4))
+	red := color.NRGBA{R: 255, A: 255}
+	sheet.Set(4, 0, red)
+	if err := utils.SaveImgByExt(filepath.Join(dir, "run.png"), sheet); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.json"), []byte(asepriteSheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := pack.UnpackSprites(filepath.Join(dir, "run.json")); err != nil {
+		t.Fatalf("Failed to unpack sprites: %v", err)
+	}
+	img, err := utils.LoadImg(filepath.Join(dir, "run 1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the trimmed frame is restored at its offset in the source size
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 4 || color.NRGBAModel.Convert(img.At(1, 0)) != red {
+		t.Errorf("unexpected frame %v", img.Bounds())
+	}
+}
+
+func TestSliceGrid(t *testing.T) {
+	dir := t.TempDir()
+	// 3x2 cells of 10x10 with a margin and spacing of 1, the last row is empty but the first cell
+	sheet := image.NewNRGBA(image.Rect(0, 0, 34, 23))
+	red := color.NRGBA{R: 255, A: 255}
+	sheet.Set(1, 1, red)
+	sheet.Set(12+9, 1+9, red)
+	sheet.Set(1+5, 12+5, red)
+	if err := utils.SaveImgByExt(filepath.Join(dir, "sheet.png"), sheet); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, grid := range []pack.GridOpts{pack.WithCellSize(10, 10), pack.WithCells(3, 2)} {
+		atlasInfo, err := pack.SliceGrid(filepath.Join(dir, "sheet.png"), grid, pack.WithMargin(1), pack.WithSpacing(1), pack.WithSkipEmpty(true))
+		if err != nil {
+			t.Fatalf("SliceGrid failed: %v", err)
+		}
+		atlas := atlasInfo.Atlases[0]
+		if atlas.Name != "sheet.png" || len(atlas.Sprites) != 3 {
+			t.Fatalf("got %s with %d sprites", atlas.Name, len(atlas.Sprites))
+		}
+		for i, want := range []struct {
+			name string
+			x, y int
+		}{{"sheet_0.png", 1, 1}, {"sheet_1.png", 12, 1}, {"sheet_3.png", 1, 12}} {
+			s := atlas.Sprites[i]
+			if s.FileName != want.name || s.Frame.X != want.x || s.Frame.Y != want.y || s.Frame.W != 10 || s.Frame.H != 10 {
+				t.Errorf("sprite %d: got %s %+v", i, s.FileName, s.Frame)
+			}
+		}
+		if err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(dir)); err != nil {
+			t.Fatalf("UnpackAtlas failed: %v", err)
+		}
+	}
+	img, err := utils.LoadImg(filepath.Join(dir, "sheet_1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 10 || color.NRGBAModel.Convert(img.At(9, 9)) != red {
+		t.Errorf("unexpected cell %v", img.Bounds())
+	}
+	if _, err := pack.SliceGrid(filepath.Join(dir, "sheet.png"), pack.WithCellSize(40, 40)); err == nil {
+		t.Errorf("expected an error for cells larger than the image")
+	}
+}
+
+func TestDetectSprites(t *testing.T) {
+	dir := t.TempDir()
+	sheet := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	red := color.NRGBA{R: 255, A: 255}
+	// a 3x3 block with a diagonal neighbour
+	for y := 1; y < 4; y++ {
+		for x := 1; x < 4; x++ {
+			sheet.Set(x, y, red)
+		}
+	}
+	sheet.Set(4, 4, red)
+	// a particle of two 2x2 parts 2 pixels apart
+	for _, x := range []int{10, 11, 14, 15} {
+		sheet.Set(x, 2, red)
+		sheet.Set(x, 3, red)
+	}
+	// a stray pixel below the tolerance and one above
+	sheet.Set(1, 8, color.NRGBA{R: 255, A: 10})
+	sheet.Set(18, 8, red)
+	if err := utils.SaveImgByExt(filepath.Join(dir, "old.png"), sheet); err != nil {
+		t.Fatal(err)
+	}
+
+	atlasInfo, err := pack.DetectSprites(filepath.Join(dir, "old.png"),
+		pack.WithAlphaTolerance(16), pack.WithMergeDistance(2), pack.WithMinSize(2))
+	if err != nil {
+		t.Fatalf("DetectSprites failed: %v", err)
+	}
+	atlas := atlasInfo.Atlases[0]
+	want := []model.Rect{model.NewRectByPosAndSize(1, 1, 4, 4), model.NewRectByPosAndSize(10, 2, 6, 2)}
+	if len(atlas.Sprites) != len(want) {
+		t.Fatalf("got %d sprites: %+v", len(atlas.Sprites), atlas.Sprites)
+	}
+	for i, s := range atlas.Sprites {
+		if s.FileName != fmt.Sprintf("old_%d.png", i) || s.Frame.Point != want[i].Point || s.Frame.Size != want[i].Size {
+			t.Errorf("sprite %d: got %s %+v", i, s.FileName, s.Frame)
+		}
+	}
+
+	// without merging the particle parts are separate sprites
+	atlasInfo, _ = pack.DetectSprites(filepath.Join(dir, "old.png"), pack.WithAlphaTolerance(16), pack.WithMinSize(2))
+	if n := len(atlasInfo.Atlases[0].Sprites); n != 3 {
+		t.Errorf("got %d sprites without merging", n)
+	}
+}
+
+func TestUnpackOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := utils.SaveImgByExt(filepath.Join(dir, "atlas.png"), image.NewNRGBA(image.Rect(0, 0, 128, 64))); err != nil {
+		t.Fatal(err)
+	}
+	atlasInfo := sampleAtlasInfo()
+	out := filepath.Join(dir, "out")
+	err := pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(out),
+		pack.WithFilter("*ed.png"), pack.WithRegexFilter("^trim"), pack.WithTrimmedOnly(true),
+		pack.WithNameTemplate("{{.Atlas}}/{{.AtlasIndex}}_{{.Index}}_{{upper .Name}}"), pack.WithImgFormat("bmp"))
+	if err == nil {
+		t.Fatalf("expected an error for an unknown template function")
+	}
+	err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(out),
+		pack.WithFilter("*ed.png"), pack.WithRegexFilter("^trim"), pack.WithTrimmedOnly(true),
+		pack.WithNameTemplate("{{.Atlas}}/{{.AtlasIndex}}_{{.Index}}_{{.Name}}"), pack.WithImgFormat("bmp"))
+	if err != nil {
+		t.Fatalf("UnpackAtlas failed: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(out, "atlas"))
+	if len(entries) != 1 || entries[0].Name() != "0_1_trimmed.bmp" {
+		t.Fatalf("unexpected output %v", entries)
+	}
+	img, err := utils.LoadImg(filepath.Join(out, "atlas", "0_1_trimmed.bmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 10 {
+		t.Errorf("trimmed sprite: got %v", img.Bounds())
+	}
+
+	// restored to the source size by default
+	if err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(out), pack.WithFilter("rot*")); err != nil {
+		t.Fatalf("UnpackAtlas failed: %v", err)
+	}
+	if img, err = utils.LoadImg(filepath.Join(out, "rotated.png")); err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 16 {
+		t.Errorf("rotated sprite: got %v", img.Bounds())
+	}
+	if err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(out), pack.WithAtlases(1)); err == nil {
+		t.Errorf("expected an error for an atlas index out of range")
+	}
+}
+
+func TestUnpackAnimation(t *testing.T) {
+	dir := t.TempDir()
+	atlasImg := image.NewNRGBA(image.Rect(0, 0, 34, 10))
+	red := color.NRGBA{R: 255, A: 255}
+	atlasImg.Set(0, 0, red)
+	atlasImg.Set(20, 0, red)
+	if err := utils.SaveImgByExt(filepath.Join(dir, "atlas.png"), atlasImg); err != nil {
+		t.Fatal(err)
+	}
+	atlasInfo := &model.AtlasInfo{
+		Atlases: []model.Atlas{{
+			Name: "atlas.png",
+			Size: model.Size{W: 34, H: 10},
+			Sprites: []model.Sprite{
+				{FileName: "run_1.png", Frame: model.NewRectByPosAndSize(0, 0, 10, 10), SrcRect: model.Size{W: 10, H: 10}},
+				{FileName: "run_10.png", Frame: model.NewRectByPosAndSize(10, 0, 10, 10), SrcRect: model.Size{W: 10, H: 10}},
+				{
+					FileName:    "run_2.png",
+					Frame:       model.NewRectByPosAndSize(20, 0, 4, 4),
+					SrcRect:     model.Size{W: 10, H: 10},
+					TrimmedRect: model.NewRectByPosAndSize(6, 6, 4, 4),
+					Trimmed:     true,
+				},
+				{FileName: "idle.png", Frame: model.NewRectByPosAndSize(24, 0, 10, 10), SrcRect: model.Size{W: 10, H: 10}},
+			},
+		}},
+	}
+	out := filepath.Join(dir, "out")
+	if err := pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(out), pack.WithAnimation("gif"), pack.WithFrameDelay(50)); err != nil {
+		t.Fatalf("UnpackAtlas failed: %v", err)
+	}
+	if err := pack.UnpackAtlas(atlasInfo, pack.WithImgInput(dir), pack.WithOutput(out), pack.WithAnimation("apng")); err != nil {
+		t.Fatalf("UnpackAtlas failed: %v", err)
+	}
+	entries, _ := os.ReadDir(out)
+	if len(entries) != 2 || entries[0].Name() != "run.gif" || entries[1].Name() != "run.png" {
+		t.Fatalf("unexpected output %v", entries)
+	}
+
+	file, err := os.Open(filepath.Join(out, "run.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[0] != 5 {
+		t.Fatalf("got %d frames, delays %v", len(anim.Image), anim.Delay)
+	}
+	// frames sorted by number, the trimmed frame restored at its offset
+	for i, p := range []image.Point{{0, 0}, {6, 6}, {-1, -1}} {
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 10; x++ {
+				_, _, _, a := anim.Image[i].At(x, y).RGBA()
+				if opaque := x == p.X && y == p.Y; (a != 0) != opaque {
+					t.Fatalf("frame %d: alpha %d at %d,%d", i, a, x, y)
+				}
+			}
+		}
+	}
+	// the default image of the apng is the first frame
+	img, err := utils.LoadImg(filepath.Join(out, "run.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 10 || color.NRGBAModel.Convert(img.At(0, 0)) != red {
+		t.Errorf("unexpected apng first frame %v", img.Bounds())
+	}
+}
+
+func TestAnimatedInputs(t *testing.T) {
+	dir := t.TempDir()
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	green := color.NRGBA{G: 255, A: 255}
+	pal := color.Palette{color.NRGBA{}, red, blue, green}
+	frame0 := image.NewPaletted(image.Rect(0, 0, 4, 4), pal)
+	frame0.Set(0, 0, red)
+	frame1 := image.NewPaletted(image.Rect(2, 2, 4, 4), pal)
+	frame1.Set(3, 3, blue)
+	frame2 := image.NewPaletted(image.Rect(0, 0, 1, 1), pal)
+	frame2.Set(0, 0, green)
+	file, err := os.Create(filepath.Join(dir, "walk.gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gif.EncodeAll(file, &gif.GIF{
+		Image:    []*image.Paletted{frame0, frame1, frame2},
+		Delay:    []int{10, 20, 30},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalBackground},
+		Config:   image.Config{ColorModel: pal, Width: 4, Height: 4},
+	})
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	apng0 := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	apng0.Set(2, 1, red)
+	apng1 := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	apng1.Set(1, 0, blue)
+	file, err = os.Create(filepath.Join(dir, "jump.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = utils.EncodeAPNG(file, []image.Image{apng0, apng1}, []int{40, 60})
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the gif frames are composed with their disposal
+	want := map[string]struct {
+		duration int
+		pixels   map[image.Point]color.NRGBA
+	}{
+		"walk_0.png": {100, map[image.Point]color.NRGBA{{0, 0}: red}},
+		"walk_1.png": {200, map[image.Point]color.NRGBA{{0, 0}: red, {3, 3}: blue}},
+		"walk_2.png": {300, map[image.Point]color.NRGBA{{0, 0}: green}},
+		"jump_0.png": {40, map[image.Point]color.NRGBA{{2, 1}: red}},
+		"jump_1.png": {60, map[image.Point]color.NRGBA{{1, 0}: blue}},
+	}
+	atlasInfo, atlasImages, err := pack.NewPacker(pack.NewOptions()).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	atlasImg := atlasImages[0].(*image.NRGBA)
+	sprites := atlasInfo.Atlases[0].Sprites
+	if len(sprites) != len(want) {
+		t.Fatalf("got %d sprites", len(sprites))
+	}
+	for _, sprite := range sprites {
+		w, ok := want[sprite.FileName]
+		if !ok || sprite.Duration != w.duration {
+			t.Errorf("unexpected sprite %s with duration %d", sprite.FileName, sprite.Duration)
+			continue
+		}
+		for y := 0; y < sprite.Frame.H; y++ {
+			for x := 0; x < sprite.Frame.W; x++ {
+				got := atlasImg.NRGBAAt(sprite.Frame.X+x, sprite.Frame.Y+y)
+				if got != w.pixels[image.Point{X: x, Y: y}] {
+					t.Errorf("%s: got %v at %d,%d", sprite.FileName, got, x, y)
+				}
+			}
+		}
+	}
+
+	// a frame named like an input file
+	if err = utils.SaveImgByExt(filepath.Join(dir, "walk_1.png"), apng0); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = pack.NewPacker(pack.NewOptions()).PackSprites(dir); err == nil {
+		t.Errorf("expected an error for the frame walk_1.png of walk.gif")
+	}
+}
+
+func TestDetectAnimations(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"run_1", "run_2", "run_10", "jump-01", "jump-02", "idle", "hit_1"} {
+		img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+		if err := utils.SaveImgByExt(filepath.Join(dir, name+".png"), img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := `{"run": {"fps": 12, "loop": false, "direction": "pingpong"}}`
+	if err := os.WriteFile(filepath.Join(dir, pack.AnimationConfigFile), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	atlasInfo, _, err := pack.NewPacker(pack.NewOptions().Animations(true)).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	if len(atlasInfo.Atlases[0].Sprites) != 7 {
+		t.Fatalf("got %d sprites, the animation config is not a sprite", len(atlasInfo.Atlases[0].Sprites))
+	}
+	want := []model.Animation{
+		{Name: "jump", Frames: []string{"jump-01.png", "jump-02.png"}},
+		{Name: "run", Frames: []string{"run_1.png", "run_2.png", "run_10.png"}, Direction: model.PingPong, Fps: 12},
+	}
+	if len(atlasInfo.Animations) != 2 || atlasInfo.Animations[1].Loop == nil || *atlasInfo.Animations[1].Loop {
+		t.Fatalf("got animations %+v", atlasInfo.Animations)
+	}
+	atlasInfo.Animations[1].Loop = nil
+	if fmt.Sprint(atlasInfo.Animations) != fmt.Sprint(want) {
+		t.Fatalf("got animations %+v", atlasInfo.Animations)
+	}
+
+	// custom pattern, the first group is the animation name
+	animations, err := pack.DetectAnimations(atlasInfo, `^(\w+)_\d$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animations) != 1 || animations[0].Name != "run" || len(animations[0].Frames) != 2 {
+		t.Errorf("custom pattern: got %+v", animations)
+	}
+	if _, err = pack.DetectAnimations(atlasInfo, `^\w+_\d+$`); err == nil {
+		t.Errorf("expected an error for a pattern without group")
+	}
+	if _, err = pack.NewOptions().AnimationPattern("(").Validate(); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+
+	data, err := (&export.JsonExporter{}).Export(atlasInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := (&export.JsonExporter{}).Import(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(imported.Animations) != fmt.Sprint(atlasInfo.Animations) {
+		t.Errorf("json round trip: got %+v", imported.Animations)
+	}
+	exporter := export.NewTemplateExporter(`{{range .Animations}}{{.Name}}:{{len .Frames}}@{{.Fps}} {{end}}`, nil)
+	data, err = exporter.Export(atlasInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "jump:2@0 run:3@12 " {
+		t.Errorf("template: got %q", data)
+	}
+}
+
+func TestSameDetectTransforms(t *testing.T) {
+	dir := t.TempDir()
+	base := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	base.Set(1, 1, color.NRGBA{R: 255, A: 255})
+	base.Set(2, 1, color.NRGBA{G: 255, A: 255})
+	base.Set(1, 3, color.NRGBA{B: 255, A: 128})
+	padded := image.NewNRGBA(image.Rect(0, 0, 14, 10))
+	draw.Draw(padded, image.Rect(4, 2, 12, 8), utils.FlipH(base), image.Point{}, draw.Src)
+	inputs := map[string]image.Image{
+		"a_base.png":    base,
+		"b_copy.png":    base,
+		"c_flipped.png": padded,
+		"d_rotated.png": utils.Rotate270(base),
+	}
+	for name, img := range inputs {
+		if err := utils.SaveImgByExt(filepath.Join(dir, name), img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the same pixels in a different file
+	if err := utils.SaveImgByExt(filepath.Join(dir, "b_copy.png"), base, utils.WithCLV(utils.NoCompression)); err != nil {
+		t.Fatal(err)
+	}
+
+	options := pack.NewOptions().Trim(true).SameDetect(true)
+	atlasInfo, _, err := pack.NewPacker(options).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	if n := len(atlasInfo.Atlases[0].Sprites); n != 4 {
+		t.Fatalf("got %d sprites", n)
+	}
+	frames := make(map[model.Rect]bool)
+	for _, sprite := range atlasInfo.Atlases[0].Sprites {
+		frames[sprite.Frame] = true
+	}
+	if len(frames) != 3 {
+		t.Errorf("only the copy should share a frame, got %d frames", len(frames))
+	}
+
+	atlasInfo, atlasImages, err := pack.NewPacker(options.SameTransform(true)).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	want := map[string]string{"a_base.png": "", "b_copy.png": "", "c_flipped.png": model.FlipX, "d_rotated.png": model.Rotate90}
+	for _, sprite := range atlasInfo.Atlases[0].Sprites {
+		if sprite.Frame != atlasInfo.Atlases[0].Sprites[0].Frame || sprite.Transform != want[sprite.FileName] {
+			t.Errorf("%s: got frame %v transform %q", sprite.FileName, sprite.Frame, sprite.Transform)
+		}
+	}
+
+	// the duplicates are restored from the shared frame
+	if err = utils.SaveImgByExt(filepath.Join(dir, "atlas", "atlas.png"), atlasImages[0]); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(filepath.Join(dir, "atlas")), pack.WithOutput(out)); err != nil {
+		t.Fatalf("UnpackAtlas failed: %v", err)
+	}
+	for name, img := range inputs {
+		got, err := utils.LoadImg(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Bounds() != img.Bounds() {
+			t.Fatalf("%s: got bounds %v", name, got.Bounds())
+		}
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if color.NRGBAModel.Convert(got.At(x, y)) != color.NRGBAModel.Convert(img.At(x, y)) {
+					t.Fatalf("%s: pixel %d,%d got %v want %v", name, x, y, got.At(x, y), img.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestSpriteAliases(t *testing.T) {
+	dir := t.TempDir()
+	inputs := make(map[string]image.Image)
+	for i, c := range []color.NRGBA{{R: 255, A: 255}, {B: 255, A: 200}} {
+		img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+		for x := 0; x < 8; x++ {
+			img.Set(x, x*i, c)
+		}
+		name := fmt.Sprintf("sprite%d", i)
+		inputs[name+".png"] = img
+		inputs[name+"_copy.png"] = img
+		if err := utils.SaveImgByExt(filepath.Join(dir, name+".png"), img); err != nil {
+			t.Fatal(err)
+		}
+		if err := utils.SaveImgByExt(filepath.Join(dir, name+"_copy.png"), img, utils.WithCLV(utils.NoCompression)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// one sprite per atlas
+	atlasInfo, atlasImages, err := pack.NewPacker(pack.NewOptions().MaxSize(8, 8).SameDetect(true)).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	if len(atlasInfo.Atlases) != 2 {
+		t.Fatalf("got %d atlases", len(atlasInfo.Atlases))
+	}
+	for i, atlas := range atlasInfo.Atlases {
+		if len(atlas.Sprites) != 2 || atlas.Sprites[0].AliasOf != "" || atlas.Sprites[1].AliasOf != atlas.Sprites[0].FileName {
+			t.Fatalf("atlas %d: got sprites %+v", i, atlas.Sprites)
+		}
+		if err = utils.SaveImgByExt(filepath.Join(dir, "atlas", atlas.Name), atlasImages[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an alias listed in another atlas than its sprite, e.g. after merging atlas infos
+	alias := atlasInfo.Atlases[1].Sprites[1]
+	alias.Frame = model.Rect{}
+	atlasInfo.Atlases[0].Sprites = append(atlasInfo.Atlases[0].Sprites, alias)
+	atlasInfo.Atlases[1].Sprites = atlasInfo.Atlases[1].Sprites[:1]
+
+	for _, atlases := range [][]int{nil, {0}} {
+		out := filepath.Join(dir, fmt.Sprint("out", atlases))
+		err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(filepath.Join(dir, "atlas")), pack.WithOutput(out), pack.WithAtlases(atlases...))
+		if err != nil {
+			t.Fatalf("UnpackAtlas failed: %v", err)
+		}
+		entries, _ := os.ReadDir(out)
+		if len(atlases) == 0 && len(entries) != 4 || len(atlases) == 1 && len(entries) != 3 {
+			t.Fatalf("atlases %v: got %d sprites", atlases, len(entries))
+		}
+		for _, entry := range entries {
+			got, err := utils.LoadImg(filepath.Join(out, entry.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := inputs[entry.Name()]
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					if color.NRGBAModel.Convert(got.At(x, y)) != want.At(x, y) {
+						t.Fatalf("%s: pixel %d,%d got %v want %v", entry.Name(), x, y, got.At(x, y), want.At(x, y))
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPolygonTrim(t *testing.T) {
+	dir := t.TempDir()
+	// an L of 21x21 pixels in a 32x32 image
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 4; y < 25; y++ {
+		for x := 3; x < 24; x++ {
+			if x < 10 || y >= 18 {
+				img.Set(x, y, color.NRGBA{G: 255, A: 255})
+			}
+		}
+	}
+	if err := utils.SaveImgByExt(filepath.Join(dir, "l.png"), img); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pack.NewOptions().Polygon(3).Validate(); err == nil {
+		t.Errorf("expected an error for a budget of 3 vertices")
+	}
+
+	for _, budget := range []int{4, 8} {
+		atlasInfo, _, err := pack.NewPacker(pack.NewOptions().Trim(true).Polygon(budget)).PackSprites(dir)
+		if err != nil {
+			t.Fatalf("PackSprites failed: %v", err)
+		}
+		sprite := atlasInfo.Atlases[0].Sprites[0]
+		// the bounding box within 4 vertices, the exact L within 8
+		want := 21 * 21
+		if budget == 8 {
+			want = 21*21 - 14*14
+		}
+		if len(sprite.Vertices) > budget || len(sprite.Triangles) != len(sprite.Vertices)-2 {
+			t.Fatalf("budget %d: got vertices %v triangles %v", budget, sprite.Vertices, sprite.Triangles)
+		}
+		area := 0
+		for _, tri := range sprite.Triangles {
+			a, b, c := sprite.Vertices[tri[0]], sprite.Vertices[tri[1]], sprite.Vertices[tri[2]]
+			area += max((b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X), (b.Y-a.Y)*(c.X-a.X)-(b.X-a.X)*(c.Y-a.Y))
+		}
+		if area != 2*want {
+			t.Errorf("budget %d: got area %d, want %d", budget, area/2, want)
+		}
+		for _, v := range sprite.Vertices {
+			if v.X < 3 || v.X > 24 || v.Y < 4 || v.Y > 25 {
+				t.Errorf("budget %d: vertex %v out of the trimmed rect", budget, v)
+			}
+		}
+	}
+}
+
+func TestPolygonAlgo(t *testing.T) {
+	dir := t.TempDir()
+	// the default options of a positional input replace the algorithm
+	if got := pack.NewOptions().Algorithm(pack.AlgoPolygon).Default().PackingAlgorithm(); got != pack.AlgoSkyline {
+		t.Errorf("got algorithm %v after Default", got)
+	}
+	// right triangles of 24x24 pixels, the lower-left ones nest into the upper-right ones
+	inputs := make(map[string]*image.NRGBA)
+	for i := 0; i < 8; i++ {
+		img := image.NewNRGBA(image.Rect(0, 0, 24, 24))
+		for y := 0; y < 24; y++ {
+			for x := 0; x < 24; x++ {
+				if i%2 == 0 && x <= y || i%2 == 1 && x >= y {
+					img.Set(x, y, color.NRGBA{R: uint8(30 * i), G: 255, A: 255})
+				}
+			}
+		}
+		name := fmt.Sprintf("tri%d.png", i)
+		inputs[name] = img
+		if err := utils.SaveImgByExt(filepath.Join(dir, name), img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// no opaque pixels, no polygon: the sprite occupies its whole rect
+	inputs["empty.png"] = image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	if err := utils.SaveImgByExt(filepath.Join(dir, "empty.png"), inputs["empty.png"]); err != nil {
+		t.Fatal(err)
+	}
+
+	rectInfo, _, err := pack.NewPacker(pack.NewOptions().Trim(true).Padding(1).Algorithm(pack.AlgoSkyline)).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	atlasInfo, atlasImages, err := pack.NewPacker(pack.NewOptions().Trim(true).Padding(1).Algorithm(pack.AlgoPolygon)).PackSprites(dir)
+	if err != nil {
+		t.Fatalf("PackSprites failed: %v", err)
+	}
+	atlas := atlasInfo.Atlases[0]
+	if atlas.Size.Area() >= rectInfo.Atlases[0].Size.Area() {
+		t.Errorf("got atlas %v, not smaller than the rect packed %v", atlas.Size, rectInfo.Atlases[0].Size)
+	}
+	overlaps := 0
+	for i, a := range atlas.Sprites {
+		if len(a.Vertices) == 0 && a.FileName != "empty.png" {
+			t.Errorf("%s: no polygon", a.FileName)
+		}
+		for _, b := range atlas.Sprites[i+1:] {
+			if !a.Frame.ToImageRect().Overlaps(b.Frame.ToImageRect()) {
+				continue
+			}
+			if a.FileName == "empty.png" || b.FileName == "empty.png" {
+				t.Errorf("%s overlaps %s: %v %v", a.FileName, b.FileName, a.Frame, b.Frame)
+			}
+			overlaps++
+		}
+	}
+	if overlaps == 0 {
+		t.Errorf("no nested sprites: %+v", atlas.Sprites)
+	}
+
+	// the nested pixels are not lost nor restored into the other sprites
+	if err = utils.SaveImgByExt(filepath.Join(dir, "atlas", atlas.Name), atlasImages[0]); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	if err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(filepath.Join(dir, "atlas")), pack.WithOutput(out)); err != nil {
+		t.Fatalf("UnpackAtlas failed: %v", err)
+	}
+	for name, want := range inputs {
+		got, err := utils.LoadImg(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Bounds().Size() != want.Bounds().Size() {
+			t.Fatalf("%s: got size %v want %v", name, got.Bounds().Size(), want.Bounds().Size())
+		}
+		for y := 0; y < want.Bounds().Dy(); y++ {
+			for x := 0; x < want.Bounds().Dx(); x++ {
+				if color.NRGBAModel.Convert(got.At(x, y)) != want.At(x, y) {
+					t.Fatalf("%s: pixel %d,%d got %v want %v", name, x, y, got.At(x, y), want.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestTrimModes(t *testing.T) {
+	dir := t.TempDir()
+	// 8x7 opaque pixels at 5,3 in a 20x16 image
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 16))
+	for y := 3; y < 10; y++ {
+		for x := 5; x < 13; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 10), G: uint8(y * 10), A: 255})
+		}
+	}
+	if err := utils.SaveImgByExt(filepath.Join(dir, "in", "a.png"), img); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pack.NewOptions().TrimMargin(-1).Validate(); err == nil {
+		t.Errorf("expected an error for a negative margin")
+	}
+	if _, err := pack.ParseTrimEdges("ltx"); err == nil {
+		t.Errorf("expected an error for an unknown edge")
+	}
+	noBottom, err := pack.ParseTrimEdges("LTR")
+	if err != nil || noBottom != pack.TrimLeft|pack.TrimTop|pack.TrimRight {
+		t.Fatalf("got edges %v, %v", noBottom, err)
+	}
+
+	tests := []struct {
+		name    string
+		mode    pack.TrimMode
+		margin  int
+		edges   pack.TrimEdge
+		src     model.Size
+		trimmed model.Rect
+		origin  image.Point // of the unpacked sprite in the image
+	}{
+		{"trim", pack.TrimModeTrim, 0, pack.TrimAllEdges, model.Size{W: 20, H: 16}, model.NewRectByPosAndSize(5, 3, 8, 7), image.Point{}},
+		{"keep", pack.TrimModeCropKeepPos, 0, pack.TrimAllEdges, model.Size{W: 13, H: 10}, model.NewRectByPosAndSize(5, 3, 8, 7), image.Point{}},
+		{"flush", pack.TrimModeCropFlush, 0, pack.TrimAllEdges, model.Size{W: 8, H: 7}, model.NewRectByPosAndSize(0, 0, 8, 7), image.Pt(5, 3)},
+		{"margin", pack.TrimModeTrim, 2, pack.TrimAllEdges, model.Size{W: 20, H: 16}, model.NewRectByPosAndSize(3, 1, 12, 11), image.Point{}},
+		{"clamped", pack.TrimModeTrim, 4, pack.TrimAllEdges, model.Size{W: 20, H: 16}, model.NewRectByPosAndSize(1, 0, 16, 14), image.Point{}},
+		{"grounded", pack.TrimModeTrim, 0, noBottom, model.Size{W: 20, H: 16}, model.NewRectByPosAndSize(5, 3, 8, 13), image.Point{}},
+		{"flushGrounded", pack.TrimModeCropFlush, 1, noBottom, model.Size{W: 10, H: 14}, model.NewRectByPosAndSize(0, 0, 10, 14), image.Pt(4, 2)},
+	}
+	for _, tt := range tests {
+		options := pack.NewOptions().Trim(true).TrimMode(tt.mode).TrimMargin(tt.margin).TrimEdges(tt.edges)
+		atlasInfo, atlasImages, err := pack.NewPacker(options).PackSprites(filepath.Join(dir, "in"))
+		if err != nil {
+			t.Fatalf("%s: PackSprites failed: %v", tt.name, err)
+		}
+		sprite := atlasInfo.Atlases[0].Sprites[0]
+		if sprite.SrcRect != tt.src || sprite.TrimmedRect != tt.trimmed || sprite.Frame.W != tt.trimmed.W || sprite.Frame.H != tt.trimmed.H {
+			t.Errorf("%s: got src %v trimmed %v frame %v, want %v %v", tt.name, sprite.SrcRect, sprite.TrimmedRect, sprite.Frame, tt.src, tt.trimmed)
+			continue
+		}
+
+		// the unpacked sprite is the cropped source
+		atlasDir := filepath.Join(dir, tt.name)
+		if err = utils.SaveImgByExt(filepath.Join(atlasDir, atlasInfo.Atlases[0].Name), atlasImages[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err = pack.UnpackAtlas(atlasInfo, pack.WithImgInput(atlasDir), pack.WithOutput(atlasDir)); err != nil {
+			t.Fatalf("%s: UnpackAtlas failed: %v", tt.name, err)
+		}
+		got, err := utils.LoadImg(filepath.Join(atlasDir, "a.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Bounds().Dx() != tt.src.W || got.Bounds().Dy() != tt.src.H {
+			t.Errorf("%s: got unpacked size %v, want %v", tt.name, got.Bounds(), tt.src)
+			continue
+		}
+		for y := 0; y < tt.src.H; y++ {
+			for x := 0; x < tt.src.W; x++ {
+				if want := img.At(tt.origin.X+x, tt.origin.Y+y); color.NRGBAModel.Convert(got.At(x, y)) != want {
+					t.Fatalf("%s: pixel %d,%d got %v want %v", tt.name, x, y, got.At(x, y), want)
+				}
+			}
+		}
+	}
+}
+
 func TestImageDiff(t *testing.T) {
 	inputFolder := "input"
 	outputFolder := "output"
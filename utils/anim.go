@@ -0,0 +1,410 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+)
+
+//----------------animation--------------------
+
+// EncodeGIF encodes the frames as a looping animated gif, delays are in milliseconds.
+// The frames must have the same bounds, each frame replaces the previous one.
+// The palette holds the exact colors when the frames have at most 255 colors, plan9 otherwise.
+func EncodeGIF(w io.Writer, frames []image.Image, delays []int) error {
+	if err := checkFrames(frames, delays); err != nil {
+		return err
+	}
+	pal := gifPalette(frames)
+	anim := &gif.GIF{LoopCount: 0}
+	for i, frame := range frames {
+		paletted := image.NewPaletted(frame.Bounds(), pal)
+		draw.Draw(paletted, paletted.Bounds(), frame, frame.Bounds().Min, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, (delays[i]+5)/10)
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// gifPalette returns transparent followed by the colors of the frames.
+func gifPalette(frames []image.Image) color.Palette {
+	pal := color.Palette{color.NRGBA{}}
+	seen := map[color.NRGBA]bool{{}: true}
+	for _, frame := range frames {
+		b := frame.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.NRGBAModel.Convert(frame.At(x, y)).(color.NRGBA)
+				if c.A < 128 {
+					continue
+				}
+				c.A = 255
+				if !seen[c] {
+					if len(pal) == 256 {
+						return append(color.Palette{color.NRGBA{}}, palette.Plan9[:255]...)
+					}
+					seen[c] = true
+					pal = append(pal, c)
+				}
+			}
+		}
+	}
+	return pal
+}
+
+// EncodeAPNG encodes the frames as a looping animated png, delays are in milliseconds.
+// The frames must have the same bounds, each frame replaces the previous one.
+func EncodeAPNG(w io.Writer, frames []image.Image, delays []int) error {
+	if err := checkFrames(frames, delays); err != nil {
+		return err
+	}
+	b := frames[0].Bounds()
+	if _, err := w.Write([]byte(pngSignature)); err != nil {
+		return err
+	}
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(b.Dy()))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 6 // color type RGBA
+	if err := writeChunk(w, "IHDR", ihdr); err != nil {
+		return err
+	}
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	// actl[4:8] number of plays, 0 loops forever
+	if err := writeChunk(w, "acTL", actl); err != nil {
+		return err
+	}
+	seq := uint32(0)
+	for i, frame := range frames {
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(b.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(b.Dy()))
+		// fctl[12:20] x and y offsets are 0
+		binary.BigEndian.PutUint16(fctl[20:], uint16(min(delays[i], 65535)))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		fctl[24] = 1 // dispose to the background
+		fctl[25] = 0 // replace the previous frame
+		if err := writeChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		seq++
+		data, err := pngImageData(frame)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			err = writeChunk(w, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, seq)
+			err = writeChunk(w, "fdAT", append(fdat, data...))
+			seq++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return writeChunk(w, "IEND", nil)
+}
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+func checkFrames(frames []image.Image, delays []int) error {
+	if len(frames) == 0 {
+		return errors.New("no frames")
+	}
+	if len(delays) != len(frames) {
+		return errors.New("the number of delays does not match the frames")
+	}
+	for _, frame := range frames[1:] {
+		if frame.Bounds() != frames[0].Bounds() {
+			return errors.New("the frames must have the same bounds")
+		}
+	}
+	return nil
+}
+
+// pngImageData returns the zlib compressed RGBA scanlines of img, without filtering.
+func pngImageData(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	nrgba := image.NewNRGBA(b)
+	draw.Draw(nrgba, b, img, b.Min, draw.Src)
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 1+4*b.Dx())
+	for y := 0; y < b.Dy(); y++ {
+		// row[0] is the filter type none
+		copy(row[1:], nrgba.Pix[y*nrgba.Stride:])
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeChunk(w io.Writer, chunkType string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], chunkType)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+	for _, part := range [][]byte{header, data, footer} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFrames loads the frames of an animated gif or png, composed on the full canvas, with their delays in milliseconds.
+// Other images are loaded as a single frame with a delay of 0.
+func LoadFrames(pathName string) ([]image.Image, []int, error) {
+	data, err := os.ReadFile(pathName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return DecFrames(bytes.NewReader(data))
+}
+
+// DecFrames decodes the frames of an animated gif or png, see LoadFrames.
+func DecFrames(r io.Reader) ([]image.Image, []int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return decodeGIFFrames(data)
+	case bytes.HasPrefix(data, []byte(pngSignature)):
+		if frames, delays, err := decodeAPNGFrames(data); err != nil || frames != nil {
+			return frames, delays, err
+		}
+	}
+	img, err := DecImg(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return []image.Image{img}, []int{0}, nil
+}
+
+// IsAPNG reports whether the png file has an animation control chunk, only the chunk headers are read.
+func IsAPNG(pathName string) bool {
+	file, err := os.Open(pathName)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	signature := make([]byte, len(pngSignature))
+	if _, err = io.ReadFull(file, signature); err != nil || string(signature) != pngSignature {
+		return false
+	}
+	header := make([]byte, 8)
+	for {
+		if _, err = io.ReadFull(file, header); err != nil {
+			return false
+		}
+		switch string(header[4:]) {
+		case "acTL":
+			return true
+		case "IDAT", "IEND":
+			return false
+		}
+		// skip the data and the crc
+		if _, err = file.Seek(int64(binary.BigEndian.Uint32(header))+4, io.SeekCurrent); err != nil {
+			return false
+		}
+	}
+}
+
+// composer draws the frames of an animation on the canvas, applying the disposal of the previous frame.
+type composer struct {
+	canvas   *image.NRGBA
+	previous *image.NRGBA // canvas before the last frame, for disposal to previous
+	rect     image.Rectangle
+	dispose  int
+}
+
+const (
+	disposeNone = iota
+	disposeBackground
+	disposePrevious
+)
+
+func newComposer(w, h int) *composer {
+	return &composer{canvas: image.NewNRGBA(image.Rect(0, 0, w, h))}
+}
+
+// draw draws a frame at rect, disposed with dispose before the next one, and returns a copy of the canvas.
+func (c *composer) draw(frame image.Image, rect image.Rectangle, op draw.Op, dispose int) image.Image {
+	switch c.dispose {
+	case disposeBackground:
+		draw.Draw(c.canvas, c.rect, image.Transparent, image.Point{}, draw.Src)
+	case disposePrevious:
+		if c.previous != nil {
+			copy(c.canvas.Pix, c.previous.Pix)
+		}
+	}
+	if dispose == disposePrevious {
+		c.previous = cloneNRGBA(c.canvas)
+	}
+	c.rect, c.dispose = rect, dispose
+	draw.Draw(c.canvas, rect, frame, frame.Bounds().Min, op)
+	return cloneNRGBA(c.canvas)
+}
+
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	clone := image.NewNRGBA(img.Rect)
+	copy(clone.Pix, img.Pix)
+	return clone
+}
+
+func decodeGIFFrames(data []byte) ([]image.Image, []int, error) {
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	c := newComposer(anim.Config.Width, anim.Config.Height)
+	frames := make([]image.Image, len(anim.Image))
+	delays := make([]int, len(anim.Image))
+	for i, frame := range anim.Image {
+		dispose := disposeNone
+		if i < len(anim.Disposal) {
+			switch anim.Disposal[i] {
+			case gif.DisposalBackground:
+				dispose = disposeBackground
+			case gif.DisposalPrevious:
+				dispose = disposePrevious
+			}
+		}
+		// transparent gif pixels keep the canvas
+		frames[i] = c.draw(frame, frame.Bounds(), draw.Over, dispose)
+		delays[i] = anim.Delay[i] * 10
+	}
+	return frames, delays, nil
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// decodeAPNGFrames returns nil frames if the png is not animated.
+func decodeAPNGFrames(data []byte) ([]image.Image, []int, error) {
+	var chunks []pngChunk
+	for rest := data[len(pngSignature):]; len(rest) >= 12; {
+		n := int(binary.BigEndian.Uint32(rest))
+		if n < 0 || 12+n > len(rest) {
+			return nil, nil, errors.New("apng: truncated chunk")
+		}
+		chunks = append(chunks, pngChunk{typ: string(rest[4:8]), data: rest[8 : 8+n]})
+		rest = rest[12+n:]
+	}
+	animated := false
+	for _, chunk := range chunks {
+		if chunk.typ == "acTL" {
+			animated = true
+		}
+	}
+	if !animated || len(chunks) == 0 || chunks[0].typ != "IHDR" || len(chunks[0].data) != 13 {
+		return nil, nil, nil
+	}
+	ihdr := chunks[0].data
+	// chunks before the image data, e.g. PLTE and tRNS, are shared by the frames
+	var shared []pngChunk
+	for _, chunk := range chunks[1:] {
+		if chunk.typ == "IDAT" || chunk.typ == "fcTL" {
+			break
+		}
+		if chunk.typ != "acTL" {
+			shared = append(shared, chunk)
+		}
+	}
+
+	type apngFrame struct {
+		fctl []byte
+		data [][]byte
+	}
+	var frameList []*apngFrame
+	var current *apngFrame
+	for _, chunk := range chunks {
+		switch chunk.typ {
+		case "fcTL":
+			if len(chunk.data) != 26 {
+				return nil, nil, errors.New("apng: invalid fcTL chunk")
+			}
+			current = &apngFrame{fctl: chunk.data}
+			frameList = append(frameList, current)
+		case "IDAT":
+			// the default image is not part of the animation without a fcTL before it
+			if current != nil {
+				current.data = append(current.data, chunk.data)
+			}
+		case "fdAT":
+			if current != nil && len(chunk.data) >= 4 {
+				current.data = append(current.data, chunk.data[4:])
+			}
+		}
+	}
+
+	c := newComposer(int(binary.BigEndian.Uint32(ihdr[0:])), int(binary.BigEndian.Uint32(ihdr[4:])))
+	var frames []image.Image
+	var delays []int
+	for _, f := range frameList {
+		w, h := binary.BigEndian.Uint32(f.fctl[4:]), binary.BigEndian.Uint32(f.fctl[8:])
+		x, y := binary.BigEndian.Uint32(f.fctl[12:]), binary.BigEndian.Uint32(f.fctl[16:])
+		// a standalone png of the frame
+		var buf bytes.Buffer
+		buf.WriteString(pngSignature)
+		frameIHDR := append([]byte(nil), ihdr...)
+		binary.BigEndian.PutUint32(frameIHDR[0:], w)
+		binary.BigEndian.PutUint32(frameIHDR[4:], h)
+		_ = writeChunk(&buf, "IHDR", frameIHDR)
+		for _, chunk := range shared {
+			_ = writeChunk(&buf, chunk.typ, chunk.data)
+		}
+		_ = writeChunk(&buf, "IDAT", bytes.Join(f.data, nil))
+		_ = writeChunk(&buf, "IEND", nil)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			return nil, nil, fmt.Errorf("apng frame %d: %v", len(frames), err)
+		}
+
+		op := draw.Src
+		if f.fctl[25] == 1 {
+			op = draw.Over
+		}
+		dispose := int(f.fctl[24])
+		// disposal to previous of the first frame is treated as to the background
+		if len(frames) == 0 && dispose == disposePrevious {
+			dispose = disposeBackground
+		}
+		rect := image.Rect(int(x), int(y), int(x+w), int(y+h))
+		frames = append(frames, c.draw(img, rect, op, dispose))
+		num, den := int(binary.BigEndian.Uint16(f.fctl[20:])), int(binary.BigEndian.Uint16(f.fctl[22:]))
+		if den == 0 {
+			den = 100
+		}
+		delays = append(delays, num*1000/den)
+	}
+	return frames, delays, nil
+}